@@ -0,0 +1,369 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 对外接口
+ */
+
+package chess
+
+import (
+	"context"
+	"errors"
+)
+
+//走子方
+const (
+	//Red 红方
+	Red = 0
+	//Black 黑方
+	Black = 1
+)
+
+var (
+	//ErrIllegalMove 走法不符合规则
+	ErrIllegalMove = errors.New("chess: illegal move")
+	//ErrSelfCheck 走完之后本方被将军
+	ErrSelfCheck = errors.New("chess: move leaves king in check")
+)
+
+//SquareXY 根据横坐标(Left~Right)和纵坐标(Top~Bottom)获得格子
+func SquareXY(x, y int) int {
+	return squareXY(x, y)
+}
+
+//SquareFlip 翻转格子
+func SquareFlip(sq int) int {
+	return squareFlip(sq)
+}
+
+//PieceSide 获得棋子属于哪一方，没有棋子返回-1
+func PieceSide(pc int) int {
+	switch {
+	case pc&sideTag(Red) != 0:
+		return Red
+	case pc&sideTag(Black) != 0:
+		return Black
+	}
+	return -1
+}
+
+//Move 走法，低8位是起点，高8位是终点
+type Move int
+
+//NewMove 根据起点和终点获得走法
+func NewMove(sqSrc, sqDst int) Move {
+	return Move(move(sqSrc, sqDst))
+}
+
+//ParseMove 把ICCS串(例如"h2e2")转换成走法
+func ParseMove(str string) (Move, error) {
+	mv, err := iccsToMove(str)
+	return Move(mv), err
+}
+
+//Src 走法的起点
+func (m Move) Src() int {
+	return src(int(m))
+}
+
+//Dst 走法的终点
+func (m Move) Dst() int {
+	return dst(int(m))
+}
+
+//String 走法的ICCS串，没有走法(0)或走法不合法时返回"(none)"
+func (m Move) String() string {
+	if !m.valid() {
+		return "(none)"
+	}
+	return moveToICCS(int(m))
+}
+
+//valid 起点和终点是否都在棋盘上
+func (m Move) valid() bool {
+	return m > 0 && m <= 0xffff && inBoard(m.Src()) && inBoard(m.Dst())
+}
+
+//Position 对局，包括局面、走过的棋和搜索
+type Position struct {
+	pos        *PositionStruct  //当前局面
+	szStartFEN string           //起始局面
+	handicap   Handicap         //让子
+	mvs        []Move           //走过的棋
+	rules      Ruleset          //和棋规则
+	result     Result           //认输、超时或议和的结果
+	book       []*BookItem      //开局库，加载之后只读，复制的对局共用
+	onInfo     func(SearchInfo) //搜索信息的回调
+	multiPV    int              //要搜索的主要变例数
+}
+
+//NewPosition 创建初始局面的对局
+func NewPosition() *Position {
+	p := &Position{
+		pos:   NewPositionStruct(),
+		rules: DefaultRuleset,
+	}
+	p.Reset()
+	return p
+}
+
+//NewPositionFromFEN 根据FEN串创建对局，局面不合法时返回ValidationError
+func NewPositionFromFEN(fen string) (*Position, error) {
+	p := &Position{
+		pos:   NewPositionStruct(),
+		rules: DefaultRuleset,
+	}
+	if err := p.pos.FromFEN(fen); err != nil {
+		return nil, err
+	}
+	//不合法的局面不能进入搜索
+	if vs := p.pos.validate(); len(vs) > 0 {
+		return nil, ValidationError(vs)
+	}
+	p.szStartFEN = p.pos.ToFEN()
+	return p, nil
+}
+
+//Clone 复制对局，不包括置换表，只读的开局库共用，复制出的对局可以在另一个goroutine中使用
+func (p *Position) Clone() *Position {
+	posClone := *p
+	posClone.pos = p.pos.clone()
+	posClone.mvs = append([]Move(nil), p.mvs...)
+	return &posClone
+}
+
+//CloneInto 把对局复制到dst并返回dst，dst已经分配的置换表和历史表留下来接着用，dst为nil时同Clone
+//dst不能正在另一个goroutine中搜索
+func (p *Position) CloneInto(dst *Position) *Position {
+	if dst == nil {
+		return p.Clone()
+	}
+	if dst == p {
+		return p
+	}
+	search, mvs := dst.pos.search, dst.mvs
+	*dst = *p
+	dst.pos = p.pos.clone()
+	dst.pos.search = search
+	if search != nil {
+		search.BookTable = p.book
+	}
+	dst.mvs = append(mvs[:0], p.mvs...)
+	return dst
+}
+
+//LoadBook 加载开局库
+func (p *Position) LoadBook() bool {
+	p.useSearch()
+	ok := p.pos.loadBook()
+	p.book = p.pos.search.BookTable
+	return ok
+}
+
+//useSearch 第一次搜索或加载开局库时才创建置换表，只创建局面时不用分配
+func (p *Position) useSearch() {
+	if p.pos.search == nil {
+		p.pos.search = newSearch()
+		p.pos.search.BookTable = p.book
+	}
+}
+
+//Ruleset 和棋规则
+func (p *Position) Ruleset() Ruleset {
+	return p.rules
+}
+
+//SetRuleset 设置和棋规则，对人和电脑的走法同样有效
+func (p *Position) SetRuleset(r Ruleset) {
+	p.rules = r
+}
+
+//Reset 回到起始局面，从FEN开始的对局回到FEN局面，否则回到初始局面，有让子时拿掉让掉的棋子
+func (p *Position) Reset() {
+	if p.szStartFEN == "" || p.pos.FromFEN(p.szStartFEN) != nil {
+		p.pos.startupHandicap(p.handicap)
+		p.szStartFEN = p.pos.ToFEN()
+	}
+	p.mvs = p.mvs[:0]
+	p.result = Result{}
+}
+
+//FEN 当前局面的FEN串
+func (p *Position) FEN() string {
+	return p.pos.ToFEN()
+}
+
+//Hash 当前局面的64位Zobrist校验码，包括走子方，同一局面在任何进程中都相同
+func (p *Position) Hash() uint64 {
+	return p.pos.zobr.qwKey
+}
+
+//Side 轮到哪一方走
+func (p *Position) Side() int {
+	return p.pos.sdPlayer
+}
+
+//Piece 格子上的棋子，红子是8~14，黑子是16~22，没有棋子是0
+func (p *Position) Piece(sq int) int {
+	if sq < 0 || sq > 255 {
+		return 0
+	}
+	return p.pos.ucpcSquares[sq]
+}
+
+//Moves 走过的棋
+func (p *Position) Moves() []Move {
+	return append([]Move(nil), p.mvs...)
+}
+
+//LegalMoves 走子方所有合法的走法(走完之后本方不被将军)
+func (p *Position) LegalMoves() []Move {
+	mvs := make([]int, MaxGenMoves)
+	nLegalMoves := p.pos.generateLegalMoves(mvs)
+	result := make([]Move, nLegalMoves)
+	for i := 0; i < nLegalMoves; i++ {
+		result[i] = Move(mvs[i])
+	}
+	return result
+}
+
+//Destinations 格子上走子方棋子所有合法走法的终点
+func (p *Position) Destinations(sq int) []int {
+	result := []int{}
+	if sq < 0 || sq > 255 {
+		return result
+	}
+	for _, mv := range p.pos.legalMovesFrom(sq) {
+		result = append(result, dst(mv))
+	}
+	return result
+}
+
+//Play 走一步棋，对局已经结束(包括认输、超时、议和与按局面裁定的结果)时返回ErrGameOver
+func (p *Position) Play(mv Move) error {
+	//对局已经结束就不能再走棋
+	if p.Result().IsOver() {
+		return ErrGameOver
+	}
+	if !mv.valid() || !p.pos.legalMove(int(mv)) {
+		return ErrIllegalMove
+	}
+	if !p.pos.makeMove(int(mv)) {
+		return ErrSelfCheck
+	}
+	//当前局面就是搜索的根节点
+	p.pos.nDistance = 0
+	p.mvs = append(p.mvs, mv)
+	return nil
+}
+
+//Undo 撤消上一步棋
+func (p *Position) Undo() bool {
+	if len(p.mvs) == 0 {
+		return false
+	}
+	p.result = Result{}
+	p.mvs = p.mvs[:len(p.mvs)-1]
+	p.pos.undoMakeMove()
+	p.pos.nDistance = 0
+	return true
+}
+
+//IsCheck 走子方是否被将军
+func (p *Position) IsCheck() bool {
+	return p.pos.checked()
+}
+
+//Captured 上一步是否吃子
+func (p *Position) Captured() bool {
+	return len(p.mvs) > 0 && p.pos.captured()
+}
+
+//BestMove 电脑思考DefaultMoveTime搜索出的最佳走法，没有走法时返回0
+func (p *Position) BestMove() Move {
+	return p.Search(SearchLimits{})
+}
+
+//Search 在limits限制内搜索最佳走法，没有走法时返回0
+func (p *Position) Search(limits SearchLimits) Move {
+	return p.SearchContext(context.Background(), limits)
+}
+
+//SetInfoHandler 设置搜索信息的回调，每次迭代完成和找到新的最佳走法时在搜索的goroutine中调用，为nil时不报告
+func (p *Position) SetInfoHandler(f func(SearchInfo)) {
+	p.onInfo = f
+}
+
+//SetMultiPV 设置要搜索的主要变例数，大于1时按名次报告前n个走法，并且不加随机性分值
+func (p *Position) SetMultiPV(n int) {
+	p.multiPV = n
+}
+
+//SearchContext 在limits限制内搜索最佳走法，ctx取消时停止，返回最后一次完整迭代的最佳走法
+func (p *Position) SearchContext(ctx context.Context, limits SearchLimits) Move {
+	return p.AnalyzeContext(ctx, limits).BestMove
+}
+
+//Analyze 在limits限制内搜索，返回最佳走法、分值和主要变例
+func (p *Position) Analyze(limits SearchLimits) SearchInfo {
+	return p.AnalyzeContext(context.Background(), limits)
+}
+
+//AnalyzeContext 在limits限制内搜索，ctx取消时停止，返回最后一次完整迭代的搜索信息
+func (p *Position) AnalyzeContext(ctx context.Context, limits SearchLimits) SearchInfo {
+	lines := p.AnalyzeMultiPV(ctx, limits)
+	if len(lines) == 0 {
+		return SearchInfo{}
+	}
+	return lines[0]
+}
+
+//AnalyzeMultiPV 在limits限制内搜索，ctx取消时停止，返回最后一次完整迭代的各条主要变例，按名次排列
+func (p *Position) AnalyzeMultiPV(ctx context.Context, limits SearchLimits) []SearchInfo {
+	p.useSearch()
+	p.pos.search.onInfo = p.onInfo
+	p.pos.search.nMultiPV = p.multiPV
+	p.pos.searchMain(ctx, limits)
+	p.pos.nDistance = 0
+	return append([]SearchInfo(nil), p.pos.search.lines...)
+}
+
+//ChineseLine 把从当前局面开始的一串走法(例如主要变例)转换成中文记谱
+func (p *Position) ChineseLine(mvs []Move) ([]string, error) {
+	pos := p.pos.clone()
+	strs := make([]string, 0, len(mvs))
+	for _, mv := range mvs {
+		str, err := pos.moveToChinese(int(mv))
+		if err != nil {
+			return nil, err
+		}
+		if !pos.legalMove(int(mv)) || !pos.makeMove(int(mv)) {
+			return nil, ErrIllegalMove
+		}
+		strs = append(strs, str)
+	}
+	return strs, nil
+}
+
+//Chinese 把走法转换成中文记谱，例如"炮二平五"，走法不是走子方的合法走法时返回错误
+func (p *Position) Chinese(mv Move) (string, error) {
+	return p.pos.moveToChinese(int(mv))
+}
+
+//ParseChinese 把中文记谱转换成走法
+func (p *Position) ParseChinese(str string) (Move, error) {
+	mv, err := p.pos.chineseToMove(str)
+	return Move(mv), err
+}
+
+//WXF 把走法转换成WXF记谱，例如"C2.5"，走法不是走子方的合法走法时返回错误
+func (p *Position) WXF(mv Move) (string, error) {
+	return p.pos.moveToWXF(int(mv))
+}
+
+//ParseWXF 把WXF记谱转换成走法
+func (p *Position) ParseWXF(str string) (Move, error) {
+	mv, err := p.pos.wxfToMove(str)
+	return Move(mv), err
+}
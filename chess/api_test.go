@@ -0,0 +1,218 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 对外接口测试
+ */
+
+package chess
+
+import (
+	"context"
+	"testing"
+)
+
+//playMoves 按ICCS串依次走棋
+func playMoves(t *testing.T, p *Position, strs ...string) {
+	t.Helper()
+	for _, str := range strs {
+		mv, err := ParseMove(str)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Play(mv); err != nil {
+			t.Fatalf("%s: %v", str, err)
+		}
+	}
+}
+
+//TestSquareAndPiece 格子坐标、翻转和棋子所属的一方
+func TestSquareAndPiece(t *testing.T) {
+	sq := SquareXY(Left, Top)
+	if SquareFlip(sq) != SquareXY(Right, Bottom) || SquareFlip(SquareFlip(sq)) != sq {
+		t.Errorf("SquareFlip(%d) = %d", sq, SquareFlip(sq))
+	}
+	cases := []struct {
+		pc   int
+		want int
+	}{
+		{0, -1},
+		{8 + PieceJiang, Red},
+		{8 + PieceBing, Red},
+		{16 + PieceJiang, Black},
+		{16 + PieceBing, Black},
+	}
+	for _, c := range cases {
+		if got := PieceSide(c.pc); got != c.want {
+			t.Errorf("PieceSide(%d) = %d, want %d", c.pc, got, c.want)
+		}
+	}
+}
+
+//TestMoveICCS 走法的起点、终点和ICCS串
+func TestMoveICCS(t *testing.T) {
+	mv := NewMove(SquareXY(Left+7, Bottom-2), SquareXY(Left+4, Bottom-2))
+	if mv.Src() != SquareXY(Left+7, Bottom-2) || mv.Dst() != SquareXY(Left+4, Bottom-2) {
+		t.Errorf("src %d, dst %d", mv.Src(), mv.Dst())
+	}
+	if mv.String() != "h2e2" {
+		t.Errorf("String() = %q", mv.String())
+	}
+	if mvParsed, err := ParseMove("h2e2"); err != nil || mvParsed != mv {
+		t.Errorf("ParseMove(h2e2) = %v, %v", mvParsed, err)
+	}
+}
+
+//TestNewPosition 初始局面
+func TestNewPosition(t *testing.T) {
+	p := NewPosition()
+	if p.FEN() != StartupFEN {
+		t.Errorf("FEN() = %q", p.FEN())
+	}
+	if p.Side() != Red || p.IsCheck() || p.Captured() || len(p.Moves()) != 0 {
+		t.Errorf("side %d, check %v, captured %v, moves %v", p.Side(), p.IsCheck(), p.Captured(), p.Moves())
+	}
+	if n := len(p.LegalMoves()); n != 44 {
+		t.Errorf("%d legal moves, want 44", n)
+	}
+	if pc := p.Piece(SquareXY(Left+4, Bottom)); pc != 8+PieceJiang {
+		t.Errorf("piece on e0 = %d", pc)
+	}
+	if p.Piece(-1) != 0 || p.Piece(256) != 0 {
+		t.Error("piece outside the board")
+	}
+}
+
+//TestNewPositionFromFEN 不合法的FEN串返回错误
+func TestNewPositionFromFEN(t *testing.T) {
+	for _, fen := range []string{"", "rnbakabnr/9 w", "rnbakabnr/9/1c5c1/p1p1p1p1p/9/9/P1P1P1P1P/1C5C1/9/RNBAKABNX w"} {
+		if _, err := NewPositionFromFEN(fen); err == nil {
+			t.Errorf("%q: no error", fen)
+		}
+	}
+	p, err := NewPositionFromFEN("4k4/9/9/9/9/9/9/9/4R4/3K5 b - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Side() != Black || !p.IsCheck() {
+		t.Errorf("side %d, check %v, want black in check", p.Side(), p.IsCheck())
+	}
+}
+
+//TestPlayUndo 走棋、吃子、将军和撤消
+func TestPlayUndo(t *testing.T) {
+	p := NewPosition()
+	//炮二平五，马8进7，炮五进四吃中兵
+	playMoves(t, p, "h2e2", "h9g7", "e2e6")
+	if !p.Captured() || p.Side() != Black || len(p.Moves()) != 3 {
+		t.Errorf("captured %v, side %d, moves %v", p.Captured(), p.Side(), p.Moves())
+	}
+	if p.IsCheck() {
+		t.Error("cannon on e6 checks without a screen")
+	}
+	for i := 0; i < 3; i++ {
+		if !p.Undo() {
+			t.Fatalf("undo %d failed", i)
+		}
+	}
+	if p.Undo() {
+		t.Error("undo at the start position")
+	}
+	if p.FEN() != StartupFEN {
+		t.Errorf("FEN after undo %q", p.FEN())
+	}
+}
+
+//TestPlayErrors 不合法的走法和送将的走法
+func TestPlayErrors(t *testing.T) {
+	p := NewPosition()
+	for _, mv := range []Move{0, -1, 0x10000, NewMove(SquareXY(Left, Bottom), SquareXY(Left+1, Bottom))} {
+		if err := p.Play(mv); err != ErrIllegalMove {
+			t.Errorf("Play(%d) = %v, want ErrIllegalMove", int(mv), err)
+		}
+	}
+
+	//红车被黑车牵制，离开将所在的直线就送将
+	p, err := NewPositionFromFEN("4k4/4r4/9/9/9/9/9/9/4R4/3K5 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	playMoves(t, p, "d0e0", "e9d9")
+	mv, _ := ParseMove("e1a1")
+	if err := p.Play(mv); err != ErrSelfCheck {
+		t.Errorf("pinned rook: Play(e1a1) = %v, want ErrSelfCheck", err)
+	}
+	if len(p.Moves()) != 2 {
+		t.Errorf("moves %v after a rejected move", p.Moves())
+	}
+}
+
+//TestCloneInto 复制到已有的对局时，局面和走过的棋跟原来一样，置换表留下来接着用
+func TestCloneInto(t *testing.T) {
+	p := NewPosition()
+	dst := p.CloneInto(nil)
+	if mv := dst.Search(SearchLimits{Depth: 2}); mv == 0 {
+		t.Fatal("no best move")
+	}
+	search := dst.pos.search
+
+	playMoves(t, p, "h2e2", "h9g7")
+	if p.CloneInto(dst) != dst {
+		t.Fatal("CloneInto did not return dst")
+	}
+	if dst.FEN() != p.FEN() || len(dst.Moves()) != 2 || dst.Hash() != p.Hash() {
+		t.Errorf("clone %q %v, want %q %v", dst.FEN(), dst.Moves(), p.FEN(), p.Moves())
+	}
+	if dst.pos.search != search {
+		t.Error("search tables were not reused")
+	}
+
+	//复制出的对局走棋不影响原来的对局
+	if mv := dst.Search(SearchLimits{Depth: 2}); mv == 0 || dst.Play(mv) != nil {
+		t.Fatalf("clone: %v", mv)
+	}
+	if len(p.Moves()) != 2 {
+		t.Errorf("original moves %v", p.Moves())
+	}
+}
+
+//TestMultiPVAtLeastOne 主要变例数小于1时按1条搜索
+func TestMultiPVAtLeastOne(t *testing.T) {
+	for _, n := range []int{0, -3, 1} {
+		p := NewPosition()
+		p.SetMultiPV(n)
+		lines := p.AnalyzeMultiPV(context.Background(), SearchLimits{Depth: 2})
+		if len(lines) != 1 || lines[0].BestMove == 0 {
+			t.Errorf("multipv %d: %v", n, lines)
+		}
+	}
+
+	//直接用搜索对象时也不能没有主要变例
+	p := NewPosition()
+	p.useSearch()
+	p.pos.search.nMultiPV = 0
+	p.pos.searchMain(context.Background(), SearchLimits{Depth: 2})
+	if len(p.pos.search.lines) != 1 {
+		t.Errorf("searchMain with nMultiPV 0: %v", p.pos.search.lines)
+	}
+}
+
+//TestConcurrentClones 复制出的对局可以同时搜索，各自用自己的随机数
+func TestConcurrentClones(t *testing.T) {
+	p := NewPosition()
+	ps := []*Position{p.Clone(), p.Clone(), p.Clone()}
+	done := make(chan Move, len(ps))
+	for _, pos := range ps {
+		go func(pos *Position) {
+			done <- pos.Search(SearchLimits{Depth: 3})
+		}(pos)
+	}
+	for range ps {
+		if mv := <-done; mv == 0 {
+			t.Error("no best move")
+		}
+	}
+	if ps[0].pos.search.rnd == ps[1].pos.search.rnd {
+		t.Error("clones share a random source")
+	}
+}
@@ -0,0 +1,541 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 暗棋
+ */
+
+package chess
+
+import (
+	"context"
+	"math/rand"
+)
+
+//暗棋规则：
+//1. 32枚棋子背面朝上随机放在半个棋盘的4x8个格子里，每一步可以翻开一枚暗子，或者走一枚本方的明子；
+//2. 第一步翻开的棋子是哪一方，先走的一方就执哪一方；
+//3. 明子横竖走一格，可以吃相邻的等级不高于自己的对方明子，但帅(将)不能吃兵(卒)，兵(卒)可以吃帅(将)；
+//4. 炮(包)横竖走一格，吃子时必须隔一枚棋子(明暗都可以)跳吃，可以吃任何对方明子；
+//5. 棋子(包括暗子)被吃光或者无棋可走的一方判负，连续BanqiDrawMoves回合没有吃子也没有翻子判和。
+//棋盘格子编号sq = x + y*BanqiWidth，走法编号mv = sqSrc + sqDst*256，翻子的走法起点和终点相同。
+
+const (
+	//BanqiWidth 暗棋棋盘宽度
+	BanqiWidth = 4
+	//BanqiHeight 暗棋棋盘高度
+	BanqiHeight = 8
+	//BanqiSquares 暗棋棋盘格子数
+	BanqiSquares = BanqiWidth * BanqiHeight
+	//BanqiDrawMoves 连续多少回合没有吃子也没有翻子判和
+	BanqiDrawMoves = 50
+)
+
+//ccBanqiRank 暗棋棋子等级，顺序同PieceJiang...PieceBing
+var ccBanqiRank = [7]int{7, 6, 5, 3, 4, 2, 1}
+
+//ccBanqiValue 暗棋子力价值，顺序同PieceJiang...PieceBing
+var ccBanqiValue = [7]int{500, 250, 120, 30, 60, 90, 20}
+
+//ccBanqiDelta 暗棋横竖四个方向的步长(dx, dy)
+var ccBanqiDelta = [4][2]int{{0, -1}, {-1, 0}, {1, 0}, {0, 1}}
+
+//banqiStep 从sq往某个方向走一步，出了棋盘返回-1
+func banqiStep(sq, i int) int {
+	x := sq%BanqiWidth + ccBanqiDelta[i][0]
+	y := sq/BanqiWidth + ccBanqiDelta[i][1]
+	if x < 0 || x >= BanqiWidth || y < 0 || y >= BanqiHeight {
+		return -1
+	}
+	return x + y*BanqiWidth
+}
+
+//banqiCanCapture 暗棋中棋子ptSrc能否吃相邻的棋子ptDst(不包括炮的跳吃)
+func banqiCanCapture(ptSrc, ptDst int) bool {
+	if ptSrc == PiecePao {
+		return false
+	}
+	if ptSrc == PieceJiang && ptDst == PieceBing {
+		return false
+	}
+	if ptSrc == PieceBing && ptDst == PieceJiang {
+		return true
+	}
+	return ccBanqiRank[ptSrc] >= ccBanqiRank[ptDst]
+}
+
+//BanqiMove 暗棋走法，翻子时起点和终点相同
+type BanqiMove struct {
+	Src int //起点
+	Dst int //终点
+}
+
+//NoBanqiMove 没有走法时的走法，不是合法走法
+var NoBanqiMove = BanqiMove{Src: -1, Dst: -1}
+
+//IsFlip 是否是翻子
+func (m BanqiMove) IsFlip() bool {
+	return m.Src == m.Dst
+}
+
+//banqiUndo 撤消暗棋走法需要的信息
+type banqiUndo struct {
+	mv         int  //走法
+	pcCaptured int  //被吃的棋子
+	bFlip      bool //是否翻子
+	pcHidden   int  //翻子之前格子上暗子的真实身份
+	sdPlayer   int  //走之前的走子方
+}
+
+//Banqi 暗棋对局
+type Banqi struct {
+	ucpcSquares [BanqiSquares]int  //棋盘上的棋子，暗子放的是真实身份，只有裁判知道，搜索不能用
+	bHidden     [BanqiSquares]bool //格子上是否是暗子
+	nPool       [24]int            //还没翻开的暗子里各种棋子的数量
+	sdPlayer    int                //轮到谁走，0=红方，1=黑方，翻开第一枚棋子之前为-1
+	undos       []banqiUndo        //撤消信息
+	nDistance   int                //距离根节点的步数
+	mvResult    int                //电脑走的棋，没有时为-1
+	searchClock                    //搜索限制
+}
+
+//NewBanqi 创建暗棋对局，相同的seed打乱的结果相同
+func NewBanqi(seed int64) *Banqi {
+	b := &Banqi{}
+	b.Reset(seed)
+	return b
+}
+
+//Clone 复制对局，复制出的对局可以在另一个goroutine中搜索
+func (b *Banqi) Clone() *Banqi {
+	bClone := *b
+	bClone.undos = append([]banqiUndo(nil), b.undos...)
+	return &bClone
+}
+
+//Reset 重新开始，用seed打乱暗子
+func (b *Banqi) Reset(seed int64) {
+	rnd := rand.New(rand.NewSource(seed))
+	pcs := []int{}
+	for sq := 0; sq < 256; sq++ {
+		if pc := cucpcStartup[sq]; pc != 0 {
+			pcs = append(pcs, pc)
+		}
+	}
+	rnd.Shuffle(len(pcs), func(i, j int) {
+		pcs[i], pcs[j] = pcs[j], pcs[i]
+	})
+
+	b.nPool = [24]int{}
+	for sq, pc := range pcs {
+		b.ucpcSquares[sq] = pc
+		b.bHidden[sq] = true
+		b.nPool[pc]++
+	}
+	b.sdPlayer = -1
+	b.undos = b.undos[:0]
+	b.nDistance = 0
+}
+
+//generateMoves 生成暗棋走法，bCapture为true时只生成明子的吃子走法
+func (b *Banqi) generateMoves(mvs []int, bCapture bool) int {
+	nGenMoves := 0
+	for sqSrc := 0; sqSrc < BanqiSquares; sqSrc++ {
+		if b.bHidden[sqSrc] {
+			if !bCapture {
+				mvs[nGenMoves] = move(sqSrc, sqSrc)
+				nGenMoves++
+			}
+			continue
+		}
+		pcSrc := b.ucpcSquares[sqSrc]
+		if pcSrc == 0 || b.sdPlayer < 0 || PieceSide(pcSrc) != b.sdPlayer {
+			continue
+		}
+
+		ptSrc := pcSrc & 7
+		for i := 0; i < 4; i++ {
+			sqDst := banqiStep(sqSrc, i)
+			if sqDst < 0 {
+				continue
+			}
+			pcDst := b.ucpcSquares[sqDst]
+			if pcDst == 0 {
+				if !bCapture {
+					mvs[nGenMoves] = move(sqSrc, sqDst)
+					nGenMoves++
+				}
+			} else if !b.bHidden[sqDst] && PieceSide(pcDst) != b.sdPlayer && banqiCanCapture(ptSrc, pcDst&7) {
+				mvs[nGenMoves] = move(sqSrc, sqDst)
+				nGenMoves++
+			}
+		}
+
+		//炮(包)隔一枚棋子跳吃
+		if ptSrc != PiecePao {
+			continue
+		}
+		for i := 0; i < 4; i++ {
+			sqDst := banqiStep(sqSrc, i)
+			for sqDst >= 0 && b.ucpcSquares[sqDst] == 0 {
+				sqDst = banqiStep(sqDst, i)
+			}
+			if sqDst < 0 {
+				continue
+			}
+			sqDst = banqiStep(sqDst, i)
+			for sqDst >= 0 && b.ucpcSquares[sqDst] == 0 {
+				sqDst = banqiStep(sqDst, i)
+			}
+			if sqDst >= 0 && !b.bHidden[sqDst] && PieceSide(b.ucpcSquares[sqDst]) != b.sdPlayer {
+				mvs[nGenMoves] = move(sqSrc, sqDst)
+				nGenMoves++
+			}
+		}
+	}
+	return nGenMoves
+}
+
+//makeMove 走一步棋，翻子时翻开成pcFlip
+func (b *Banqi) makeMove(mv, pcFlip int) {
+	sqSrc := src(mv)
+	sqDst := dst(mv)
+	u := banqiUndo{
+		mv:       mv,
+		bFlip:    sqSrc == sqDst,
+		sdPlayer: b.sdPlayer,
+	}
+	if u.bFlip {
+		u.pcHidden = b.ucpcSquares[sqSrc]
+		b.ucpcSquares[sqSrc] = pcFlip
+		b.bHidden[sqSrc] = false
+		b.nPool[pcFlip]--
+		//第一步翻开的棋子决定先走一方执哪一方
+		if b.sdPlayer < 0 {
+			b.sdPlayer = PieceSide(pcFlip)
+		}
+	} else {
+		u.pcCaptured = b.ucpcSquares[sqDst]
+		b.ucpcSquares[sqDst] = b.ucpcSquares[sqSrc]
+		b.ucpcSquares[sqSrc] = 0
+	}
+	b.sdPlayer = 1 - b.sdPlayer
+	b.undos = append(b.undos, u)
+	b.nDistance++
+}
+
+//undoMakeMove 撤消走一步棋
+func (b *Banqi) undoMakeMove() {
+	u := b.undos[len(b.undos)-1]
+	b.undos = b.undos[:len(b.undos)-1]
+	b.nDistance--
+	sqSrc := src(u.mv)
+	sqDst := dst(u.mv)
+	if u.bFlip {
+		b.nPool[b.ucpcSquares[sqSrc]]++
+		b.ucpcSquares[sqSrc] = u.pcHidden
+		b.bHidden[sqSrc] = true
+	} else {
+		b.ucpcSquares[sqSrc] = b.ucpcSquares[sqDst]
+		b.ucpcSquares[sqDst] = u.pcCaptured
+	}
+	b.sdPlayer = u.sdPlayer
+}
+
+//noProgressMoves 上一次吃子或翻子之后走了多少步
+func (b *Banqi) noProgressMoves() int {
+	nMoves := 0
+	for i := len(b.undos) - 1; i >= 0 && !b.undos[i].bFlip && b.undos[i].pcCaptured == 0; i-- {
+		nMoves++
+	}
+	return nMoves
+}
+
+//evaluate 局面评价函数，暗子按暗子池算到各方的子力里
+func (b *Banqi) evaluate() int {
+	if b.sdPlayer < 0 {
+		return 0
+	}
+	vl := [2]int{}
+	for sq := 0; sq < BanqiSquares; sq++ {
+		if pc := b.ucpcSquares[sq]; pc != 0 && !b.bHidden[sq] {
+			vl[PieceSide(pc)] += ccBanqiValue[pc&7]
+		}
+	}
+	for pc := 8; pc < 24; pc++ {
+		if b.nPool[pc] > 0 {
+			vl[PieceSide(pc)] += b.nPool[pc] * ccBanqiValue[pc&7]
+		}
+	}
+	return vl[b.sdPlayer] - vl[1-b.sdPlayer] + AdvancedValue
+}
+
+//sortMoves 生成走法并按被吃棋子的价值排序，mvFirst排在最前面
+func (b *Banqi) sortMoves(bCapture bool, mvFirst int) []int {
+	mvs := make([]int, BanqiSquares*8)
+	mvs = mvs[:b.generateMoves(mvs, bCapture)]
+	vls := make([]int, len(mvs))
+	for i, mv := range mvs {
+		if mv == mvFirst {
+			vls[i] = MateValue
+		} else if pc := b.ucpcSquares[dst(mv)]; pc != 0 && src(mv) != dst(mv) {
+			vls[i] = ccBanqiValue[pc&7]
+		}
+	}
+	//插入排序，走法不多
+	for i := 1; i < len(mvs); i++ {
+		mv, vl := mvs[i], vls[i]
+		k := i
+		for ; k > 0 && vls[k-1] < vl; k-- {
+			mvs[k], vls[k] = mvs[k-1], vls[k-1]
+		}
+		mvs[k], vls[k] = mv, vl
+	}
+	return mvs
+}
+
+//searchMove 走一步棋并搜索，返回对走子方的分值
+//翻子是机会节点，按暗子池里各种棋子的比例取期望值，用Star1裁剪
+func (b *Banqi) searchMove(mv, vlAlpha, vlBeta, nDepth int) int {
+	if src(mv) != dst(mv) {
+		b.makeMove(mv, 0)
+		vl := -b.searchFull(-vlBeta, -vlAlpha, nDepth)
+		b.undoMakeMove()
+		return vl
+	}
+
+	//第一步翻开的棋子决定执哪一方，分值都是对翻子的一方来说的
+	nTotal := 0
+	for pc := 8; pc < 24; pc++ {
+		nTotal += b.nPool[pc]
+	}
+	nRest, vlSum := nTotal, 0
+	for pc := 8; pc < 24; pc++ {
+		n := b.nPool[pc]
+		if n == 0 {
+			continue
+		}
+		nRest -= n
+		vlChildAlpha := (vlAlpha*nTotal - vlSum - MateValue*nRest) / n
+		if vlChildAlpha < -MateValue {
+			vlChildAlpha = -MateValue
+		}
+		vlChildBeta := (vlBeta*nTotal - vlSum + MateValue*nRest) / n
+		if vlChildBeta > MateValue {
+			vlChildBeta = MateValue
+		}
+
+		b.makeMove(mv, pc)
+		vl := -b.searchFull(-vlChildBeta, -vlChildAlpha, nDepth)
+		b.undoMakeMove()
+
+		vlSum += n * vl
+		if vl <= vlChildAlpha {
+			return (vlSum + MateValue*nRest) / nTotal
+		}
+		if vl >= vlChildBeta {
+			return (vlSum - MateValue*nRest) / nTotal
+		}
+	}
+	return vlSum / nTotal
+}
+
+//searchQuiesc 静态(Quiescence)搜索过程，只搜索明子的吃子走法
+func (b *Banqi) searchQuiesc(vlAlpha, vlBeta int) int {
+	if b.stopped() {
+		return 0
+	}
+	vlBest := b.evaluate()
+	if vlBest >= vlBeta || b.nDistance >= LimitDepth {
+		return vlBest
+	}
+	if vlBest > vlAlpha {
+		vlAlpha = vlBest
+	}
+	for _, mv := range b.sortMoves(true, -1) {
+		vl := b.searchMove(mv, vlAlpha, vlBeta, 0)
+		if vl > vlBest {
+			if vl >= vlBeta {
+				return vl
+			}
+			vlBest = vl
+			if vl > vlAlpha {
+				vlAlpha = vl
+			}
+		}
+	}
+	return vlBest
+}
+
+//searchFull 超出边界(Fail-Soft)的Alpha-Beta搜索过程，根节点记录最佳走法
+func (b *Banqi) searchFull(vlAlpha, vlBeta, nDepth int) int {
+	if nDepth <= 0 {
+		return b.searchQuiesc(vlAlpha, vlBeta)
+	}
+	if b.stopped() {
+		return 0
+	}
+
+	mvFirst := -1
+	if b.nDistance == 0 {
+		mvFirst = b.mvResult
+	}
+	vlBest, mvBest := -MateValue, -1
+	for _, mv := range b.sortMoves(false, mvFirst) {
+		vl := b.searchMove(mv, vlAlpha, vlBeta, nDepth-1)
+		if vl > vlBest {
+			vlBest = vl
+			mvBest = mv
+			if vl >= vlBeta {
+				break
+			}
+			if vl > vlAlpha {
+				vlAlpha = vl
+			}
+		}
+	}
+
+	//无棋可走判负
+	if mvBest < 0 {
+		return b.nDistance - MateValue
+	}
+	//超出搜索限制的那一层搜索结果不完整，不能用
+	if b.nDistance == 0 && !b.bStop {
+		b.mvResult = mvBest
+	}
+	return vlBest
+}
+
+//searchMain 迭代加深搜索过程，超出limits或ctx取消时停止
+func (b *Banqi) searchMain(ctx context.Context, limits SearchLimits) {
+	b.startClock(ctx, limits, b.sdPlayer)
+	b.nDistance = 0
+	b.mvResult = -1
+	for i := 1; i <= limits.maxDepth(); i++ {
+		vl := b.searchFull(-MateValue, MateValue, i)
+		//超出搜索限制或被取消，或者搜索到杀棋(无限分析时继续)，就终止搜索
+		if b.bStop || (!limits.Infinite && (vl > WinValue || vl < -WinValue)) {
+			break
+		}
+	}
+	b.waitCancel()
+	//第一层都没有搜完，随便走一步
+	if b.mvResult < 0 {
+		if mvs := b.sortMoves(false, -1); len(mvs) > 0 {
+			b.mvResult = mvs[0]
+		}
+	}
+}
+
+//Side 轮到谁走，翻开第一枚棋子之前为-1
+func (b *Banqi) Side() int {
+	return b.sdPlayer
+}
+
+//Piece 格子上的明子，空格子和暗子返回0
+func (b *Banqi) Piece(sq int) int {
+	if sq < 0 || sq >= BanqiSquares || b.bHidden[sq] {
+		return 0
+	}
+	return b.ucpcSquares[sq]
+}
+
+//Hidden 格子上是否是暗子
+func (b *Banqi) Hidden(sq int) bool {
+	return sq >= 0 && sq < BanqiSquares && b.bHidden[sq]
+}
+
+//Moves 走过的棋
+func (b *Banqi) Moves() []BanqiMove {
+	result := make([]BanqiMove, len(b.undos))
+	for i, u := range b.undos {
+		result[i] = BanqiMove{src(u.mv), dst(u.mv)}
+	}
+	return result
+}
+
+//LegalMoves 走子方所有合法走法
+func (b *Banqi) LegalMoves() []BanqiMove {
+	mvs := make([]int, BanqiSquares*8)
+	nGenMoves := b.generateMoves(mvs, false)
+	result := make([]BanqiMove, nGenMoves)
+	for i := 0; i < nGenMoves; i++ {
+		result[i] = BanqiMove{src(mvs[i]), dst(mvs[i])}
+	}
+	return result
+}
+
+//Play 走一步棋，翻子时按真实身份翻开
+func (b *Banqi) Play(m BanqiMove) error {
+	if b.Result().IsOver() {
+		return ErrGameOver
+	}
+	for _, mv := range b.LegalMoves() {
+		if mv == m {
+			b.makeMove(move(m.Src, m.Dst), b.ucpcSquares[m.Src])
+			b.nDistance = 0
+			return nil
+		}
+	}
+	return ErrIllegalMove
+}
+
+//Undo 撤消上一步棋
+func (b *Banqi) Undo() bool {
+	if len(b.undos) == 0 {
+		return false
+	}
+	b.undoMakeMove()
+	b.nDistance = 0
+	return true
+}
+
+//Captured 上一步是否吃子
+func (b *Banqi) Captured() bool {
+	return len(b.undos) > 0 && b.undos[len(b.undos)-1].pcCaptured != 0
+}
+
+//Result 对局结果，棋子被吃光或者无棋可走的一方判负
+func (b *Banqi) Result() Result {
+	if b.sdPlayer < 0 {
+		return Result{}
+	}
+	//只有走子方的最后一枚棋子会被吃掉，暗子也算
+	bPieces := false
+	for _, pc := range b.ucpcSquares {
+		if pc != 0 && PieceSide(pc) == b.sdPlayer {
+			bPieces = true
+			break
+		}
+	}
+	if !bPieces {
+		return winResult(1-b.sdPlayer, ReasonNoPieces)
+	}
+	if len(b.LegalMoves()) == 0 {
+		return winResult(1-b.sdPlayer, ReasonStalemate)
+	}
+	if b.noProgressMoves() >= BanqiDrawMoves*2 {
+		return drawResult(ReasonMoveLimit)
+	}
+	return Result{}
+}
+
+//BestMove 电脑思考DefaultMoveTime搜索出的最佳走法，电脑不知道暗子的真实身份，没有走法时返回NoBanqiMove和false
+func (b *Banqi) BestMove() (BanqiMove, bool) {
+	return b.Search(SearchLimits{})
+}
+
+//Search 在limits限制内搜索最佳走法，没有走法时返回NoBanqiMove和false
+func (b *Banqi) Search(limits SearchLimits) (BanqiMove, bool) {
+	return b.SearchContext(context.Background(), limits)
+}
+
+//SearchContext 在limits限制内搜索最佳走法，ctx取消时停止，返回最后一次完整迭代的最佳走法，没有走法时返回NoBanqiMove和false
+func (b *Banqi) SearchContext(ctx context.Context, limits SearchLimits) (BanqiMove, bool) {
+	b.searchMain(ctx, limits)
+	b.nDistance = 0
+	if b.mvResult < 0 {
+		return NoBanqiMove, false
+	}
+	return BanqiMove{src(b.mvResult), dst(b.mvResult)}, true
+}
@@ -0,0 +1,101 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 暗棋测试
+ */
+
+package chess
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+//isBanqiLegal 走法是否在暗棋的合法走法里
+func isBanqiLegal(b *Banqi, mv BanqiMove) bool {
+	for _, mvLegal := range b.LegalMoves() {
+		if mvLegal == mv {
+			return true
+		}
+	}
+	return false
+}
+
+//TestBanqiSearchLimits 暗棋搜索也按SearchLimits限制，ctx取消时停止
+func TestBanqiSearchLimits(t *testing.T) {
+	b := NewBanqi(1)
+	for i := 0; i < 4; i++ {
+		mv, ok := b.Search(SearchLimits{Depth: 1})
+		if !ok || b.Play(mv) != nil {
+			t.Fatalf("flip %d: %v %v", i, mv, ok)
+		}
+	}
+	if mv, ok := b.Search(SearchLimits{Depth: 2}); !ok || !isBanqiLegal(b, mv) {
+		t.Errorf("depth 2: illegal move %v", mv)
+	}
+	if mv, ok := b.Search(SearchLimits{Nodes: 1000}); !ok || !isBanqiLegal(b, mv) || b.nNodes > 1000 {
+		t.Errorf("nodes 1000: move %v after %d nodes", mv, b.nNodes)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	tStart := time.Now()
+	mv, ok := b.SearchContext(ctx, SearchLimits{Infinite: true})
+	if d := time.Since(tStart); d > time.Second {
+		t.Errorf("infinite search took %v after cancel", d)
+	}
+	if !ok || !isBanqiLegal(b, mv) {
+		t.Errorf("infinite: illegal move %v", mv)
+	}
+}
+
+//TestBanqiClone 复制出的对局走棋不影响原来的对局
+func TestBanqiClone(t *testing.T) {
+	b := NewBanqi(1)
+	if err := b.Play(BanqiMove{Src: 0, Dst: 0}); err != nil {
+		t.Fatal(err)
+	}
+	bClone := b.Clone()
+	mv, ok := bClone.Search(SearchLimits{Depth: 2})
+	if !ok || bClone.Play(mv) != nil {
+		t.Fatalf("clone: %v %v", mv, ok)
+	}
+	if len(b.Moves()) != 1 || len(bClone.Moves()) != 2 {
+		t.Errorf("moves %v, clone moves %v", b.Moves(), bClone.Moves())
+	}
+	if !b.Undo() || !b.Hidden(0) || bClone.Hidden(0) {
+		t.Error("undo on the original changed the clone")
+	}
+}
+
+//TestBanqiNoPieces 棋子被吃光的一方马上判负，暗子也算没吃光的棋子，没有走法时搜索返回NoBanqiMove
+func TestBanqiNoPieces(t *testing.T) {
+	b := &Banqi{sdPlayer: Red, mvResult: -1}
+	b.ucpcSquares[0] = sideTag(Red) + PieceJu
+	b.ucpcSquares[1] = sideTag(Black) + PieceBing
+	b.ucpcSquares[BanqiSquares-1] = sideTag(Black) + PieceJu
+	b.bHidden[BanqiSquares-1] = true
+	b.nPool[sideTag(Black)+PieceJu] = 1
+	if err := b.Play(BanqiMove{Src: 0, Dst: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if r := b.Result(); r.IsOver() {
+		t.Fatalf("black still has a hidden piece: %v", r)
+	}
+
+	b.Undo()
+	b.ucpcSquares[BanqiSquares-1] = 0
+	b.bHidden[BanqiSquares-1] = false
+	b.nPool[sideTag(Black)+PieceJu] = 0
+	if err := b.Play(BanqiMove{Src: 0, Dst: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if r := b.Result(); r != winResult(Red, ReasonNoPieces) {
+		t.Errorf("got %v, want red wins by no pieces", r)
+	}
+	if mv, ok := b.Search(SearchLimits{Depth: 1}); ok || mv != NoBanqiMove {
+		t.Errorf("search with no moves: got %v %v, want %v false", mv, ok, NoBanqiMove)
+	}
+}
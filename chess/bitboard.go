@@ -0,0 +1,357 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 位棋盘走法生成
+ */
+
+package chess
+
+import (
+	"math/bits"
+)
+
+//位棋盘只用到棋盘上的90个格子，编号是x+y*9，x和y从棋盘左上角算起
+const (
+	//BoardFiles 棋盘的列数
+	BoardFiles = 9
+	//BoardRanks 棋盘的行数
+	BoardRanks = 10
+	//BoardSquares 棋盘的格子数
+	BoardSquares = BoardFiles * BoardRanks
+)
+
+//bitboard 90个格子的位棋盘，lo是第0~63格，hi是第64~89格
+type bitboard struct {
+	lo, hi uint64
+}
+
+//set 把格子加入位棋盘
+func (b *bitboard) set(sq90 int) {
+	if sq90 < 64 {
+		b.lo |= 1 << uint(sq90)
+	} else {
+		b.hi |= 1 << uint(sq90-64)
+	}
+}
+
+//clear 把格子从位棋盘中去掉
+func (b *bitboard) clear(sq90 int) {
+	if sq90 < 64 {
+		b.lo &^= 1 << uint(sq90)
+	} else {
+		b.hi &^= 1 << uint(sq90-64)
+	}
+}
+
+//and 两个位棋盘的交集
+func (b bitboard) and(bb bitboard) bitboard {
+	return bitboard{b.lo & bb.lo, b.hi & bb.hi}
+}
+
+//andNot 去掉另一个位棋盘中的格子
+func (b bitboard) andNot(bb bitboard) bitboard {
+	return bitboard{b.lo &^ bb.lo, b.hi &^ bb.hi}
+}
+
+//isEmpty 位棋盘是否为空
+func (b bitboard) isEmpty() bool {
+	return b.lo|b.hi == 0
+}
+
+//pop 取出编号最小的格子，位棋盘不能为空
+func (b *bitboard) pop() int {
+	if b.lo != 0 {
+		sq90 := bits.TrailingZeros64(b.lo)
+		b.lo &= b.lo - 1
+		return sq90
+	}
+	sq90 := bits.TrailingZeros64(b.hi) + 64
+	b.hi &= b.hi - 1
+	return sq90
+}
+
+//slideStruct 一行或一列上车、炮的走法预生成数组，下标是这一行(列)的占位
+type slideStruct struct {
+	wNonCap [1024]uint16 //不吃子的走法
+	wJuCap  [1024]uint16 //车吃子的走法
+	wPaoCap [1024]uint16 //炮吃子的走法
+}
+
+var (
+	//ccSquare90 256格的编号转换成90格的编号，棋盘外是-1
+	ccSquare90 [256]int
+	//ccSquare256 90格的编号转换成256格的编号
+	ccSquare256 [BoardSquares]int
+	//bbBoard 整个棋盘
+	bbBoard bitboard
+	//ccJiangAttack 帅(将)的走法
+	ccJiangAttack [BoardSquares]bitboard
+	//ccShiAttack 仕(士)的走法
+	ccShiAttack [BoardSquares]bitboard
+	//ccXiangEye 相(象)眼的位置(256格编号)
+	ccXiangEye [2][BoardSquares][4]int
+	//ccXiangAttack 相(象)眼没有棋子时的走法
+	ccXiangAttack [2][BoardSquares][4]bitboard
+	//ccMaLeg 马腿的位置(256格编号)
+	ccMaLeg [BoardSquares][4]int
+	//ccMaAttack 马腿没有棋子时的走法
+	ccMaAttack [BoardSquares][4]bitboard
+	//ccMaCheckLeg 马将军时的马腿位置(以仕(士)的步长当作马腿)
+	ccMaCheckLeg [BoardSquares][4]int
+	//ccMaCheckAttack 马腿没有棋子时能将军的马的位置
+	ccMaCheckAttack [BoardSquares][4]bitboard
+	//ccBingAttack 兵(卒)的走法
+	ccBingAttack [2][BoardSquares]bitboard
+	//ccBingCheck 能将军的兵(卒)的位置
+	ccBingCheck [2][BoardSquares]bitboard
+	//ccRankSlide 每一行上每个位置的车、炮走法
+	ccRankSlide [BoardFiles]slideStruct
+	//ccFileSlide 每一列上每个位置的车、炮走法
+	ccFileSlide [BoardRanks]slideStruct
+)
+
+//init 初始化位棋盘的走法预生成数组
+func init() {
+	for sq := 0; sq < 256; sq++ {
+		ccSquare90[sq] = -1
+	}
+	for sq90 := 0; sq90 < BoardSquares; sq90++ {
+		sq := squareXY(sq90%BoardFiles+Left, sq90/BoardFiles+Top)
+		ccSquare90[sq] = sq90
+		ccSquare256[sq90] = sq
+		bbBoard.set(sq90)
+	}
+
+	//90格位棋盘中加入一个256格编号的格子，棋盘外的格子不加入
+	add := func(b *bitboard, sq int) {
+		if sq >= 0 && sq < 256 && inBoard(sq) {
+			b.set(ccSquare90[sq])
+		}
+	}
+	for sq90, sq := range ccSquare256 {
+		for i := 0; i < 4; i++ {
+			//帅(将)和仕(士)只能在九宫中走
+			if inFort(sq + ccJiangDelta[i]) {
+				add(&ccJiangAttack[sq90], sq+ccJiangDelta[i])
+			}
+			if inFort(sq + ccShiDelta[i]) {
+				add(&ccShiAttack[sq90], sq+ccShiDelta[i])
+			}
+
+			//相(象)眼必须在本方的棋盘上
+			for sd := 0; sd < 2; sd++ {
+				sqEye := sq + ccShiDelta[i]
+				if inBoard(sqEye) && noRiver(sqEye, sd) {
+					ccXiangEye[sd][sq90][i] = sqEye
+					add(&ccXiangAttack[sd][sq90][i], sqEye+ccShiDelta[i])
+				}
+			}
+
+			//棋盘外的马腿上没有棋子，不会蹩马腿
+			ccMaLeg[sq90][i] = sq + ccJiangDelta[i]
+			ccMaCheckLeg[sq90][i] = sq + ccShiDelta[i]
+			for j := 0; j < 2; j++ {
+				add(&ccMaAttack[sq90][i], sq+ccMaDelta[i][j])
+				add(&ccMaCheckAttack[sq90][i], sq+ccMaCheckDelta[i][j])
+			}
+		}
+
+		for sd := 0; sd < 2; sd++ {
+			add(&ccBingAttack[sd][sq90], squareForward(sq, sd))
+			add(&ccBingCheck[sd][sq90], squareForward(sq, sd))
+			add(&ccBingCheck[sd][sq90], sq-1)
+			add(&ccBingCheck[sd][sq90], sq+1)
+			if hasRiver(sq, sd) {
+				add(&ccBingAttack[sd][sq90], sq-1)
+				add(&ccBingAttack[sd][sq90], sq+1)
+			}
+		}
+	}
+
+	for x := 0; x < BoardFiles; x++ {
+		initSlide(&ccRankSlide[x], x, BoardFiles)
+	}
+	for y := 0; y < BoardRanks; y++ {
+		initSlide(&ccFileSlide[y], y, BoardRanks)
+	}
+}
+
+//initSlide 初始化长度为n的一行(列)上第i个位置的车、炮走法
+func initSlide(s *slideStruct, i, n int) {
+	for wOcc := 0; wOcc < 1<<uint(n); wOcc++ {
+		for _, nDelta := range []int{-1, 1} {
+			j := i + nDelta
+			for ; j >= 0 && j < n && wOcc&(1<<uint(j)) == 0; j += nDelta {
+				s.wNonCap[wOcc] |= 1 << uint(j)
+			}
+			if j < 0 || j >= n {
+				continue
+			}
+			s.wJuCap[wOcc] |= 1 << uint(j)
+			for j += nDelta; j >= 0 && j < n; j += nDelta {
+				if wOcc&(1<<uint(j)) != 0 {
+					s.wPaoCap[wOcc] |= 1 << uint(j)
+					break
+				}
+			}
+		}
+	}
+}
+
+//addBitPiece 在位棋盘上放一枚棋子
+func (p *PositionStruct) addBitPiece(sq, pc int) {
+	sq90 := ccSquare90[sq]
+	x, y := sq90%BoardFiles, sq90/BoardFiles
+	p.bbPieces[pc>>4][pc&7].set(sq90)
+	p.bbSides[pc>>4].set(sq90)
+	p.wBitRanks[y] |= 1 << uint(x)
+	p.wBitFiles[x] |= 1 << uint(y)
+}
+
+//delBitPiece 从位棋盘上拿走一枚棋子
+func (p *PositionStruct) delBitPiece(sq, pc int) {
+	sq90 := ccSquare90[sq]
+	x, y := sq90%BoardFiles, sq90/BoardFiles
+	p.bbPieces[pc>>4][pc&7].clear(sq90)
+	p.bbSides[pc>>4].clear(sq90)
+	p.wBitRanks[y] &^= 1 << uint(x)
+	p.wBitFiles[x] &^= 1 << uint(y)
+}
+
+//addMoves 把位棋盘上的目标格都生成走法，返回走法数
+func addMoves(mvs []int, nGenMoves, sqSrc int, bb bitboard) int {
+	for !bb.isEmpty() {
+		mvs[nGenMoves] = move(sqSrc, ccSquare256[bb.pop()])
+		nGenMoves++
+	}
+	return nGenMoves
+}
+
+//generateMoves 用位棋盘生成所有走法，如果bCapture为true则只生成吃子走法
+func (p *PositionStruct) generateMoves(mvs []int, bCapture bool) int {
+	nGenMoves := 0
+	sd := p.sdPlayer
+	pcOppSide := oppSideTag(sd)
+
+	//吃子走法只能走到对方棋子上，其他走法不能走到本方棋子上
+	bbTarget := bbBoard.andNot(p.bbSides[sd])
+	if bCapture {
+		bbTarget = p.bbSides[1-sd]
+	}
+
+	for bb := p.bbPieces[sd][PieceJiang]; !bb.isEmpty(); {
+		sq90 := bb.pop()
+		nGenMoves = addMoves(mvs, nGenMoves, ccSquare256[sq90], ccJiangAttack[sq90].and(bbTarget))
+	}
+	for bb := p.bbPieces[sd][PieceShi]; !bb.isEmpty(); {
+		sq90 := bb.pop()
+		nGenMoves = addMoves(mvs, nGenMoves, ccSquare256[sq90], ccShiAttack[sq90].and(bbTarget))
+	}
+	for bb := p.bbPieces[sd][PieceXiang]; !bb.isEmpty(); {
+		sq90 := bb.pop()
+		for i := 0; i < 4; i++ {
+			if p.ucpcSquares[ccXiangEye[sd][sq90][i]] == 0 {
+				nGenMoves = addMoves(mvs, nGenMoves, ccSquare256[sq90], ccXiangAttack[sd][sq90][i].and(bbTarget))
+			}
+		}
+	}
+	for bb := p.bbPieces[sd][PieceMa]; !bb.isEmpty(); {
+		sq90 := bb.pop()
+		for i := 0; i < 4; i++ {
+			if p.ucpcSquares[ccMaLeg[sq90][i]] == 0 {
+				nGenMoves = addMoves(mvs, nGenMoves, ccSquare256[sq90], ccMaAttack[sq90][i].and(bbTarget))
+			}
+		}
+	}
+	for bb := p.bbPieces[sd][PieceBing]; !bb.isEmpty(); {
+		sq90 := bb.pop()
+		nGenMoves = addMoves(mvs, nGenMoves, ccSquare256[sq90], ccBingAttack[sd][sq90].and(bbTarget))
+	}
+
+	//车和炮按所在行、列的占位查表
+	for pt := PieceJu; pt <= PiecePao; pt++ {
+		for bb := p.bbPieces[sd][pt]; !bb.isEmpty(); {
+			sq90 := bb.pop()
+			sqSrc := ccSquare256[sq90]
+			x, y := sq90%BoardFiles, sq90/BoardFiles
+			rank := &ccRankSlide[x]
+			file := &ccFileSlide[y]
+			wRank, wFile := rank.wJuCap[p.wBitRanks[y]], file.wJuCap[p.wBitFiles[x]]
+			if pt == PiecePao {
+				wRank, wFile = rank.wPaoCap[p.wBitRanks[y]], file.wPaoCap[p.wBitFiles[x]]
+			}
+			if !bCapture {
+				wRank |= rank.wNonCap[p.wBitRanks[y]]
+				wFile |= file.wNonCap[p.wBitFiles[x]]
+			}
+			for ; wRank != 0; wRank &= wRank - 1 {
+				sqDst := squareXY(bits.TrailingZeros16(wRank)+Left, y+Top)
+				if p.ucpcSquares[sqDst] == 0 || p.ucpcSquares[sqDst]&pcOppSide != 0 {
+					mvs[nGenMoves] = move(sqSrc, sqDst)
+					nGenMoves++
+				}
+			}
+			for ; wFile != 0; wFile &= wFile - 1 {
+				sqDst := squareXY(x+Left, bits.TrailingZeros16(wFile)+Top)
+				if p.ucpcSquares[sqDst] == 0 || p.ucpcSquares[sqDst]&pcOppSide != 0 {
+					mvs[nGenMoves] = move(sqSrc, sqDst)
+					nGenMoves++
+				}
+			}
+		}
+	}
+	return nGenMoves
+}
+
+//checked 用位棋盘判断是否被将军
+func (p *PositionStruct) checked() bool {
+	sd := p.sdPlayer
+	bbJiang := p.bbPieces[sd][PieceJiang]
+	if bbJiang.isEmpty() {
+		return false
+	}
+	sq90 := bbJiang.pop()
+	pcOppSide := oppSideTag(sd)
+
+	//判断是否被对方的兵(卒)将军
+	if !ccBingCheck[sd][sq90].and(p.bbPieces[1-sd][PieceBing]).isEmpty() {
+		return true
+	}
+
+	//判断是否被对方的马将军
+	for i := 0; i < 4; i++ {
+		if p.ucpcSquares[ccMaCheckLeg[sq90][i]] == 0 &&
+			!ccMaCheckAttack[sq90][i].and(p.bbPieces[1-sd][PieceMa]).isEmpty() {
+			return true
+		}
+	}
+
+	//判断是否被对方的车或炮将军(包括将帅对脸)
+	x, y := sq90%BoardFiles, sq90/BoardFiles
+	rank := &ccRankSlide[x]
+	file := &ccFileSlide[y]
+	for w := rank.wJuCap[p.wBitRanks[y]]; w != 0; w &= w - 1 {
+		pc := p.ucpcSquares[squareXY(bits.TrailingZeros16(w)+Left, y+Top)]
+		if pc == pcOppSide+PieceJu || pc == pcOppSide+PieceJiang {
+			return true
+		}
+	}
+	for w := file.wJuCap[p.wBitFiles[x]]; w != 0; w &= w - 1 {
+		pc := p.ucpcSquares[squareXY(x+Left, bits.TrailingZeros16(w)+Top)]
+		if pc == pcOppSide+PieceJu || pc == pcOppSide+PieceJiang {
+			return true
+		}
+	}
+	for w := rank.wPaoCap[p.wBitRanks[y]]; w != 0; w &= w - 1 {
+		if p.ucpcSquares[squareXY(bits.TrailingZeros16(w)+Left, y+Top)] == pcOppSide+PiecePao {
+			return true
+		}
+	}
+	for w := file.wPaoCap[p.wBitFiles[x]]; w != 0; w &= w - 1 {
+		if p.ucpcSquares[squareXY(x+Left, bits.TrailingZeros16(w)+Top)] == pcOppSide+PiecePao {
+			return true
+		}
+	}
+	return false
+}
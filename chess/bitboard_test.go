@@ -0,0 +1,238 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 位棋盘走法生成测试
+ */
+
+package chess
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+)
+
+//moveGenerator 走法生成器，用来比较位棋盘和逐格扫描两种实现
+type moveGenerator struct {
+	generateMoves func(p *PositionStruct, mvs []int, bCapture bool) int
+	checked       func(p *PositionStruct) bool
+}
+
+var (
+	//genBitboard 位棋盘走法生成
+	genBitboard = moveGenerator{(*PositionStruct).generateMoves, (*PositionStruct).checked}
+	//genMailbox 逐格扫描走法生成
+	genMailbox = moveGenerator{(*PositionStruct).generateMovesMailbox, (*PositionStruct).checkedMailbox}
+)
+
+//perftWith 用指定的走法生成器统计叶子节点数，mvs是每层的走法缓冲区
+func (p *PositionStruct) perftWith(g *moveGenerator, mvs [][]int, nDepth int) int {
+	nNodes := 0
+	nGenMoves := g.generateMoves(p, mvs[nDepth-1], false)
+	for i := 0; i < nGenMoves; i++ {
+		mv := mvs[nDepth-1][i]
+		pcCaptured := p.movePiece(mv)
+		if !g.checked(p) {
+			if nDepth == 1 {
+				nNodes++
+			} else {
+				p.changeSide()
+				nNodes += p.perftWith(g, mvs, nDepth-1)
+				p.changeSide()
+			}
+		}
+		p.undoMovePiece(mv, pcCaptured)
+	}
+	return nNodes
+}
+
+//compareMoves 比较两种走法生成的结果(不计顺序)
+func (p *PositionStruct) compareMoves(bCapture bool) error {
+	mvs1 := make([]int, MaxGenMoves)
+	mvs2 := make([]int, MaxGenMoves)
+	mvs1 = mvs1[:p.generateMoves(mvs1, bCapture)]
+	mvs2 = mvs2[:p.generateMovesMailbox(mvs2, bCapture)]
+	sort.Ints(mvs1)
+	sort.Ints(mvs2)
+	if fmt.Sprint(mvs1) != fmt.Sprint(mvs2) {
+		return fmt.Errorf("%q: bitboard moves %v, mailbox moves %v", p.ToFEN(), mvs1, mvs2)
+	}
+	return nil
+}
+
+//compareGenerators 在搜索树的每个节点上比较两种走法生成和将军判断
+func (p *PositionStruct) compareGenerators(nDepth int) error {
+	if err := p.compareMoves(false); err != nil {
+		return err
+	}
+	if err := p.compareMoves(true); err != nil {
+		return err
+	}
+	if p.checked() != p.checkedMailbox() {
+		return fmt.Errorf("%q: bitboard checked %v, mailbox checked %v", p.ToFEN(), p.checked(), p.checkedMailbox())
+	}
+	if nDepth <= 0 {
+		return nil
+	}
+	mvs := make([]int, MaxGenMoves)
+	nGenMoves := p.generateMoves(mvs, false)
+	for i := 0; i < nGenMoves; i++ {
+		if !p.makeMove(mvs[i]) {
+			continue
+		}
+		err := p.compareGenerators(nDepth - 1)
+		p.undoMakeMove()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//TestBitboardMatchesMailbox 在Perft局面的搜索树上逐个节点比较位棋盘和逐格扫描的走法生成
+func TestBitboardMatchesMailbox(t *testing.T) {
+	nDepth := 3
+	if testing.Short() {
+		nDepth = 2
+	}
+	for _, c := range perftCases {
+		p, err := NewPositionFromFEN(c.FEN)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.pos.compareGenerators(nDepth); err != nil {
+			t.Error(err)
+		}
+		//两种走法生成统计的叶子节点数都要和已公布的结果一致
+		mvs := make([][]int, len(c.Nodes))
+		for i := range mvs {
+			mvs[i] = make([]int, MaxGenMoves)
+		}
+		for i := 1; i <= nDepth && i <= len(c.Nodes); i++ {
+			for _, g := range []*moveGenerator{&genMailbox, &genBitboard} {
+				if nNodes := p.pos.perftWith(g, mvs, i); nNodes != c.Nodes[i-1] {
+					t.Errorf("%q depth %d: got %d, want %d", c.FEN, i, nNodes, c.Nodes[i-1])
+				}
+			}
+		}
+	}
+}
+
+//checkIncremental 增量更新的位棋盘、子力价值和校验码要和按ucpcSquares重新摆出的局面相同
+func (p *PositionStruct) checkIncremental() error {
+	q := NewPositionStruct()
+	q.clearBoard()
+	for sq, pc := range p.ucpcSquares {
+		if pc != 0 {
+			q.addPiece(sq, pc)
+		}
+	}
+	if p.sdPlayer == 1 {
+		q.changeSide()
+	}
+	if p.bbPieces != q.bbPieces || p.bbSides != q.bbSides || p.wBitRanks != q.wBitRanks || p.wBitFiles != q.wBitFiles {
+		return fmt.Errorf("%q: bitboards out of step with the board", p.ToFEN())
+	}
+	if p.vlRed != q.vlRed || p.vlBlack != q.vlBlack || p.zobr != q.zobr {
+		return fmt.Errorf("%q: values or key out of step with the board", p.ToFEN())
+	}
+	return nil
+}
+
+//checkIncrementalTree 在搜索树的每个节点上走棋和撤消以后检查增量更新的信息
+func (p *PositionStruct) checkIncrementalTree(nDepth int) error {
+	if err := p.checkIncremental(); err != nil || nDepth <= 0 {
+		return err
+	}
+	mvs := make([]int, MaxGenMoves)
+	nGenMoves := p.generateMoves(mvs, false)
+	for i := 0; i < nGenMoves; i++ {
+		if !p.makeMove(mvs[i]) {
+			if err := p.checkIncremental(); err != nil {
+				return fmt.Errorf("after illegal %s: %v", moveToICCS(mvs[i]), err)
+			}
+			continue
+		}
+		err := p.checkIncrementalTree(nDepth - 1)
+		p.undoMakeMove()
+		if err == nil {
+			err = p.checkIncremental()
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", moveToICCS(mvs[i]), err)
+		}
+	}
+	return nil
+}
+
+//TestIncrementalUpdate 走棋和撤消以后位棋盘、子力价值和校验码都和棋盘上的棋子一致
+func TestIncrementalUpdate(t *testing.T) {
+	for _, c := range perftCases {
+		p, err := NewPositionFromFEN(c.FEN)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.pos.checkIncrementalTree(2); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+//benchGenerators 要比较的走法生成器
+var benchGenerators = []struct {
+	name string
+	g    *moveGenerator
+}{
+	{"mailbox", &genMailbox},
+	{"bitboard", &genBitboard},
+}
+
+//benchPositions Perft局面
+func benchPositions(b *testing.B) []*PositionStruct {
+	ps := make([]*PositionStruct, 0, len(perftCases))
+	for _, c := range perftCases {
+		p, err := NewPositionFromFEN(c.FEN)
+		if err != nil {
+			b.Fatal(err)
+		}
+		ps = append(ps, p.pos)
+	}
+	return ps
+}
+
+//BenchmarkGenerateMoves 在Perft局面上生成全部走法
+func BenchmarkGenerateMoves(b *testing.B) {
+	ps := benchPositions(b)
+	mvs := make([]int, MaxGenMoves)
+	for _, bg := range benchGenerators {
+		b.Run(bg.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, p := range ps {
+					bg.g.generateMoves(p, mvs, false)
+				}
+			}
+		})
+	}
+}
+
+//BenchmarkPerft 在Perft局面上统计深度3的叶子节点数
+func BenchmarkPerft(b *testing.B) {
+	const nDepth = 3
+	ps := benchPositions(b)
+	mvs := make([][]int, nDepth)
+	for i := range mvs {
+		mvs[i] = make([]int, MaxGenMoves)
+	}
+	for _, bg := range benchGenerators {
+		b.Run(bg.name, func(b *testing.B) {
+			nNodes := 0
+			for i := 0; i < b.N; i++ {
+				for _, p := range ps {
+					nNodes += p.perftWith(bg.g, mvs, nDepth)
+				}
+			}
+			b.ReportMetric(float64(nNodes)/float64(b.N), "nodes/op")
+		})
+	}
+}
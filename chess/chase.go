@@ -0,0 +1,108 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 长捉检测(亚洲规则)
+ */
+
+package chess
+
+//canRecapture 对方吃掉sq上的棋子之后，走子方能否合法地吃回来
+func (p *PositionStruct) canRecapture(sq int) bool {
+	mvs := make([]int, MaxGenMoves)
+	nGenMoves := p.generateMoves(mvs, true)
+	for i := 0; i < nGenMoves; i++ {
+		if dst(mvs[i]) != sq {
+			continue
+		}
+		if p.makeMove(mvs[i]) {
+			p.undoMakeMove()
+			return true
+		}
+	}
+	return false
+}
+
+//cnChaseValue 判断捉子时的子力价值，顺序同PieceJiang...PieceBing
+var cnChaseValue = [7]int{0, 2, 2, 4, 9, 4, 1}
+
+//chasedSquares 走子方正在捉的对方棋子，按亚洲规则：
+//1. 帅(将)和兵(卒)捉子不算捉；
+//2. 没过河的兵(卒)被捉不算捉，帅(将)被捉是将军，也不算捉；
+//3. 被捉的棋子没有保护才算捉，但价值低的棋子捉价值高的棋子(例如马、炮捉车)不论有没有保护都算捉；
+//4. 捉子的走法必须合法(吃子之后本方不被将军)。
+func (p *PositionStruct) chasedSquares() [256]bool {
+	sqs := [256]bool{}
+	pcSelfSide := sideTag(p.sdPlayer)
+	pcOppSide := oppSideTag(p.sdPlayer)
+	mvs := make([]int, MaxGenMoves)
+	nGenMoves := p.generateMoves(mvs, true)
+	for i := 0; i < nGenMoves; i++ {
+		sqDst := dst(mvs[i])
+		ptSrc := p.ucpcSquares[src(mvs[i])] - pcSelfSide
+		ptDst := p.ucpcSquares[sqDst] - pcOppSide
+		if ptSrc == PieceJiang || ptSrc == PieceBing || ptDst == PieceJiang {
+			continue
+		}
+		if ptDst == PieceBing && !hasRiver(sqDst, 1-p.sdPlayer) {
+			continue
+		}
+		if sqs[sqDst] || !p.makeMove(mvs[i]) {
+			continue
+		}
+		bProtected := cnChaseValue[ptSrc] >= cnChaseValue[ptDst] && p.canRecapture(sqDst)
+		p.undoMakeMove()
+		if !bProtected {
+			sqs[sqDst] = true
+		}
+	}
+	return sqs
+}
+
+//chaseStatus 检测从第nFirst步开始到现在，双方是否每一步都在捉对方的同一个棋子
+//返回走子方是否长捉，对方是否长捉
+func (p *PositionStruct) chaseStatus(nFirst int) (bool, bool) {
+	//先退回到第nFirst步之前，再逐步重走，比较每一步前后被捉的棋子
+	sdSelf := p.sdPlayer
+	mvs := []int{}
+	for i := nFirst; i < p.nMoveNum; i++ {
+		mvs = append(mvs, p.mvsList[i].wmv)
+	}
+	for range mvs {
+		p.undoMakeMove()
+	}
+
+	//每一方每一步都在捉的棋子所在的格子，被捉的棋子走动时跟着走
+	sqsChased := [2][256]bool{}
+	bFirst := [2]bool{true, true}
+	for _, mv := range mvs {
+		sdMover := p.sdPlayer
+		sqsBefore := p.chasedSquares()
+		p.makeMove(mv)
+		if sqsChased[1-sdMover][src(mv)] {
+			sqsChased[1-sdMover][src(mv)] = false
+			sqsChased[1-sdMover][dst(mv)] = true
+		}
+		//走完之后换成走棋的一方，看新捉了哪些棋子
+		p.changeSide()
+		sqsAfter := p.chasedSquares()
+		p.changeSide()
+
+		for sq := 0; sq < 256; sq++ {
+			bChase := sqsAfter[sq] && !sqsBefore[sq]
+			sqsChased[sdMover][sq] = bChase && (bFirst[sdMover] || sqsChased[sdMover][sq])
+		}
+		bFirst[sdMover] = false
+	}
+	return anyChased(&sqsChased[sdSelf]), anyChased(&sqsChased[1-sdSelf])
+}
+
+//anyChased 是否有被捉的棋子
+func anyChased(sqs *[256]bool) bool {
+	for _, b := range sqs {
+		if b {
+			return true
+		}
+	}
+	return false
+}
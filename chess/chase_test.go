@@ -0,0 +1,95 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 长捉检测测试
+ */
+
+package chess
+
+import (
+	"testing"
+)
+
+//playCycle 从fen开始把循环走法重复走nTimes遍
+func playCycle(t *testing.T, fen string, cycle []string, nTimes int) *Position {
+	t.Helper()
+	p, err := NewPositionFromFEN(fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < nTimes; i++ {
+		for _, str := range cycle {
+			mv, err := ParseMove(str)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := p.Play(mv); err != nil {
+				t.Fatalf("%s: %v", str, err)
+			}
+		}
+	}
+	return p
+}
+
+//TestChaseResult 长捉、轮流捉不同的棋子和双方互捉
+func TestChaseResult(t *testing.T) {
+	cases := []struct {
+		name  string
+		fen   string
+		cycle []string
+		want  Result
+	}{
+		//红车一直追捉没有保护的黑马，红方长捉判负
+		{"chase", "3k5/9/n8/9/9/9/9/9/2R6/4K4 w - - 0 1",
+			[]string{"c1a1", "a7c8", "a1c1", "c8a7"}, Result{OutcomeBlackWin, ReasonPerpetualChase}},
+		//红车轮流捉两个不同的黑马，不算长捉
+		{"rotating", "3k5/9/n7n/9/9/9/9/9/R8/4K4 w - - 0 1",
+			[]string{"a1i1", "d9d8", "i1a1", "d8d9"}, Result{OutcomeDraw, ReasonRepetition}},
+		//红方每一步都捉黑炮，黑方每一步都捉红车，双方互捉判和
+		{"mutual", "3k5/9/9/8R/8C/9/9/3r3n1/N7c/4K4 w - - 0 1",
+			[]string{"i5d5", "h2i4", "d5i5", "i4h2"}, Result{OutcomeDraw, ReasonRepetition}},
+	}
+	for _, c := range cases {
+		p := playCycle(t, c.fen, c.cycle, 3)
+		if got := p.Result(); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+//TestChasedSquares 价值低的棋子捉价值高的棋子不论有没有保护都算捉
+func TestChasedSquares(t *testing.T) {
+	cases := []struct {
+		name   string
+		fen    string
+		sq     string
+		bChase bool
+	}{
+		{"rook attacks unprotected horse", "3k5/9/9/9/9/9/2n6/9/2R6/4K4 w - - 0 1", "c3", true},
+		{"rook attacks protected horse", "3k5/9/9/9/9/2r6/2n6/9/2R6/4K4 w - - 0 1", "c3", false},
+		{"horse attacks protected rook", "3k5/9/9/9/9/2r6/2r6/9/1N7/4K4 w - - 0 1", "c3", true},
+		{"cannon attacks protected horse", "3k5/9/9/9/9/2r6/2n6/2p6/2C6/4K4 w - - 0 1", "c3", false},
+	}
+	for _, c := range cases {
+		p, err := NewPositionFromFEN(c.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sq, _ := iccsToSquare(c.sq)
+		if sqs := p.pos.chasedSquares(); sqs[sq] != c.bChase {
+			t.Errorf("%s: chased %v, want %v", c.name, sqs[sq], c.bChase)
+		}
+	}
+}
+
+//TestRepStatusInSearch 搜索中只检测重复和长将，不重走循环检测长捉
+func TestRepStatusInSearch(t *testing.T) {
+	p := playCycle(t, "3k5/9/n8/9/9/9/9/9/2R6/4K4 w - - 0 1", []string{"c1a1", "a7c8", "a1c1", "c8a7"}, 1)
+	if nStatus := p.pos.repStatus(1, false); nStatus != 1 {
+		t.Errorf("search repStatus %d, want 1", nStatus)
+	}
+	if nStatus := p.pos.repStatus(1, true); nStatus != 1+8 {
+		t.Errorf("adjudication repStatus %d, want %d", nStatus, 1+8)
+	}
+}
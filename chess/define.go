@@ -7,64 +7,6 @@
 
 package chess
 
-const (
-	//ImgChessBoard 棋盘
-	ImgChessBoard = 1
-	//ImgSelect 选中
-	ImgSelect = 2
-	//ImgRedShuai 红帅
-	ImgRedShuai = 8
-	//ImgRedShi 红士
-	ImgRedShi = 9
-	//ImgRedXiang 红相
-	ImgRedXiang = 10
-	//ImgRedMa 红马
-	ImgRedMa = 11
-	//ImgRedJu 红车
-	ImgRedJu = 12
-	//ImgRedPao 红炮
-	ImgRedPao = 13
-	//ImgRedBing 红兵
-	ImgRedBing = 14
-	//ImgBlackJiang 黑将
-	ImgBlackJiang = 16
-	//ImgBlackShi 黑士
-	ImgBlackShi = 17
-	//ImgBlackXiang 黑相
-	ImgBlackXiang = 18
-	//ImgBlackMa 黑马
-	ImgBlackMa = 19
-	//ImgBlackJu 黑车
-	ImgBlackJu = 20
-	//ImgBlackPao 黑炮
-	ImgBlackPao = 21
-	//ImgBlackBing 黑兵
-	ImgBlackBing = 22
-)
-
-const (
-	//MusicSelect 选子
-	MusicSelect = 100
-	//MusicPut 落子
-	MusicPut = 101
-	//MusicEat 吃子
-	MusicEat = 102
-	//MusicJiang 将军
-	MusicJiang = 103
-	//MusicGameWin 胜利
-	MusicGameWin = 104
-	//MusicGameLose 失败
-	MusicGameLose = 105
-)
-
-//窗口
-const (
-	SquareSize  = 56
-	BoardEdge   = 8
-	BoardWidth  = BoardEdge + SquareSize*9 + BoardEdge
-	BoardHeight = BoardEdge + SquareSize*10 + BoardEdge
-)
-
 //棋盘范围
 const (
 	Top    = 3
@@ -96,8 +38,6 @@ const (
 const (
 	//MaxGenMoves 最大的生成走法数
 	MaxGenMoves = 128
-	//MaxMoves 最大的历史走法数
-	MaxMoves = 256
 	//LimitDepth 最大的搜索深度
 	LimitDepth = 64
 	//MateValue 最高分值，即将死的分值
@@ -0,0 +1,168 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * FEN局面描述
+ */
+
+package chess
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//StartupFEN 初始局面的FEN串
+const StartupFEN = "rnbakabnr/9/1c5c1/p1p1p1p1p/9/9/P1P1P1P1P/1C5C1/9/RNBAKABNR w - - 0 1"
+
+//cszPieceFEN FEN中的棋子字母，顺序同PieceJiang...PieceBing，红方大写，黑方小写
+const cszPieceFEN = "KABNRCP"
+
+//fenPiece 根据FEN字母获得棋子，无法识别返回0
+func fenPiece(c byte) int {
+	pc := sideTag(0)
+	if c >= 'a' && c <= 'z' {
+		pc = sideTag(1)
+		c -= 'a' - 'A'
+	}
+	//兼容部分软件用E表示相(象)、H表示马
+	switch c {
+	case 'E':
+		c = 'B'
+	case 'H':
+		c = 'N'
+	}
+	i := strings.IndexByte(cszPieceFEN, c)
+	if i < 0 {
+		return 0
+	}
+	return pc + i
+}
+
+//pieceFEN 获得棋子的FEN字母
+func pieceFEN(pc int) byte {
+	if pc < 16 {
+		return cszPieceFEN[pc-8]
+	}
+	return cszPieceFEN[pc-16] + 'a' - 'A'
+}
+
+//FromFEN 根据FEN串设置局面
+func (p *PositionStruct) FromFEN(fen string) error {
+	fields := strings.Fields(fen)
+	if len(fields) == 0 {
+		return errors.New("fen: empty string")
+	}
+
+	//先解析到临时棋盘，出错时不破坏当前局面
+	ucpcSquares := [256]int{}
+	ranks := strings.Split(fields[0], "/")
+	if len(ranks) != Bottom-Top+1 {
+		return fmt.Errorf("fen: %d ranks, want %d", len(ranks), Bottom-Top+1)
+	}
+	for i, rank := range ranks {
+		y := Top + i
+		x := Left
+		for j := 0; j < len(rank); j++ {
+			c := rank[j]
+			if c >= '1' && c <= '9' {
+				x += int(c - '0')
+			} else {
+				pc := fenPiece(c)
+				if pc == 0 {
+					return fmt.Errorf("fen: invalid piece %q", c)
+				}
+				if x > Right {
+					return fmt.Errorf("fen: rank %d too long", i+1)
+				}
+				ucpcSquares[squareXY(x, y)] = pc
+				x++
+			}
+			if x > Right+1 {
+				return fmt.Errorf("fen: rank %d too long", i+1)
+			}
+		}
+		if x != Right+1 {
+			return fmt.Errorf("fen: rank %d too short", i+1)
+		}
+	}
+
+	//走子方，缺省为红方
+	sdPlayer := 0
+	if len(fields) > 1 {
+		switch fields[1] {
+		case "w", "r":
+			sdPlayer = 0
+		case "b":
+			sdPlayer = 1
+		default:
+			return fmt.Errorf("fen: invalid side %q", fields[1])
+		}
+	}
+
+	//没有吃子的步数和回合数，缺省为0和1
+	nClock, nRound := 0, 1
+	if len(fields) > 4 {
+		n, err := strconv.Atoi(fields[4])
+		if err != nil || n < 0 {
+			return fmt.Errorf("fen: invalid halfmove clock %q", fields[4])
+		}
+		nClock = n
+	}
+	if len(fields) > 5 {
+		n, err := strconv.Atoi(fields[5])
+		if err != nil || n < 1 {
+			return fmt.Errorf("fen: invalid fullmove number %q", fields[5])
+		}
+		nRound = n
+	}
+
+	p.clearBoard()
+	for sq := 0; sq < 256; sq++ {
+		if ucpcSquares[sq] != 0 {
+			p.addPiece(sq, ucpcSquares[sq])
+		}
+	}
+	if sdPlayer == 1 {
+		p.changeSide()
+	}
+	p.nStartClock, p.nStartRound = nClock, nRound
+	p.setIrrev()
+	return nil
+}
+
+//ToFEN 把局面转换成FEN串
+func (p *PositionStruct) ToFEN() string {
+	var sb strings.Builder
+	for y := Top; y <= Bottom; y++ {
+		nEmpty := 0
+		for x := Left; x <= Right; x++ {
+			pc := p.ucpcSquares[squareXY(x, y)]
+			if pc == 0 {
+				nEmpty++
+				continue
+			}
+			if nEmpty > 0 {
+				sb.WriteByte(byte('0' + nEmpty))
+				nEmpty = 0
+			}
+			sb.WriteByte(pieceFEN(pc))
+		}
+		if nEmpty > 0 {
+			sb.WriteByte(byte('0' + nEmpty))
+		}
+		if y < Bottom {
+			sb.WriteByte('/')
+		}
+	}
+	if p.sdPlayer == 0 {
+		sb.WriteString(" w")
+	} else {
+		sb.WriteString(" b")
+	}
+	//黑方走完一步回合数加1
+	fmt.Fprintf(&sb, " - - %d %d", p.noCaptureMoves(), p.gamePlies()/2+1)
+	return sb.String()
+}
@@ -0,0 +1,52 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * FEN局面描述测试
+ */
+
+package chess
+
+import (
+	"testing"
+)
+
+//TestFENClock 读入和写出FEN时保留没有吃子的步数和回合数
+func TestFENClock(t *testing.T) {
+	cases := []struct {
+		fen  string
+		mvs  []string
+		want string
+	}{
+		{StartupFEN, nil, StartupFEN},
+		{StartupFEN, []string{"h2e2"}, "rnbakabnr/9/1c5c1/p1p1p1p1p/9/9/P1P1P1P1P/1C2C4/9/RNBAKABNR b - - 1 1"},
+		{StartupFEN, []string{"h2e2", "h9g7"}, "rnbakab1r/9/1c4nc1/p1p1p1p1p/9/9/P1P1P1P1P/1C2C4/9/RNBAKABNR w - - 2 2"},
+		{"3k5/9/n8/9/9/9/9/9/2R6/4K4 w - - 37 20", []string{"c1c7"}, "3k5/9/n1R6/9/9/9/9/9/9/4K4 b - - 38 20"},
+		{"3k5/9/n8/9/9/9/9/9/2R6/4K4 b - - 37 20", []string{"a7c8"}, "3k5/2n6/9/9/9/9/9/9/2R6/4K4 w - - 38 21"},
+		//吃子之后重新计数
+		{"3k5/9/n8/9/9/9/9/9/R8/4K4 w - - 37 20", []string{"a1a7"}, "3k5/9/R8/9/9/9/9/9/9/4K4 b - - 0 20"},
+		//缺省为0和1
+		{"3k5/9/n8/9/9/9/9/9/2R6/4K4 w", nil, "3k5/9/n8/9/9/9/9/9/2R6/4K4 w - - 0 1"},
+	}
+	for _, c := range cases {
+		p, err := NewPositionFromFEN(c.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, str := range c.mvs {
+			mv, _ := ParseMove(str)
+			if err := p.Play(mv); err != nil {
+				t.Fatalf("%q %s: %v", c.fen, str, err)
+			}
+		}
+		if fen := p.FEN(); fen != c.want {
+			t.Errorf("%q %v: got %q, want %q", c.fen, c.mvs, fen, c.want)
+		}
+	}
+
+	for _, fen := range []string{StartupFEN[:len(StartupFEN)-3] + "x 1", StartupFEN[:len(StartupFEN)-3] + "-1 1", StartupFEN[:len(StartupFEN)-1] + "0"} {
+		if _, err := NewPositionFromFEN(fen); err == nil {
+			t.Errorf("%q: want error", fen)
+		}
+	}
+}
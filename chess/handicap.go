@@ -0,0 +1,91 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 让子
+ */
+
+package chess
+
+import (
+	"fmt"
+)
+
+//Handicap 让子，都是红方让子
+type Handicap int
+
+const (
+	//HandicapNone 不让子
+	HandicapNone Handicap = iota
+	//HandicapMa 让单马(左马)
+	HandicapMa
+	//HandicapTwoMa 让双马
+	HandicapTwoMa
+	//HandicapJu 让单车(左车)
+	HandicapJu
+	//HandicapNine 让九子(双车、双马、双炮和一、五、九路兵)
+	HandicapNine
+)
+
+//cszHandicap 让子的名称
+var cszHandicap = [...]string{"不让子", "让单马", "让双马", "让单车", "让九子"}
+
+//ccHandicapSquares 每种让子从初始局面上拿掉的红方棋子
+var ccHandicapSquares = [...][]int{
+	{},
+	{0xc4},
+	{0xc4, 0xca},
+	{0xc3},
+	{0xc3, 0xcb, 0xc4, 0xca, 0xa4, 0xaa, 0x93, 0x97, 0x9b},
+}
+
+//Handicaps 所有让子
+func Handicaps() []Handicap {
+	result := make([]Handicap, len(cszHandicap))
+	for i := range result {
+		result[i] = Handicap(i)
+	}
+	return result
+}
+
+//String 让子的名称
+func (h Handicap) String() string {
+	if h < 0 || int(h) >= len(cszHandicap) {
+		return fmt.Sprintf("handicap %d", int(h))
+	}
+	return cszHandicap[h]
+}
+
+//ParseHandicap 根据名称找到让子
+func ParseHandicap(name string) (Handicap, error) {
+	for i, str := range cszHandicap {
+		if str == name {
+			return Handicap(i), nil
+		}
+	}
+	return HandicapNone, fmt.Errorf("chess: unknown handicap %q", name)
+}
+
+//startupHandicap 初始化让子的棋盘
+func (p *PositionStruct) startupHandicap(h Handicap) {
+	p.startup()
+	if h < 0 || int(h) >= len(ccHandicapSquares) {
+		return
+	}
+	for _, sq := range ccHandicapSquares[h] {
+		p.delPiece(sq, p.ucpcSquares[sq])
+	}
+	p.setIrrev()
+}
+
+//Handicap 当前对局的让子
+func (p *Position) Handicap() Handicap {
+	return p.handicap
+}
+
+//SetHandicap 设置让子并从让子的初始局面重新开始
+func (p *Position) SetHandicap(h Handicap) {
+	p.handicap = h
+	p.szStartFEN = ""
+	p.Reset()
+}
@@ -0,0 +1,70 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 让子测试
+ */
+
+package chess
+
+import "testing"
+
+//isLegal 走法是否在对局的合法走法里
+func isLegal(p *Position, mv Move) bool {
+	for _, mvLegal := range p.LegalMoves() {
+		if mvLegal == mv {
+			return true
+		}
+	}
+	return false
+}
+
+//TestParseHandicap 让子的名称能解析回原来的让子，不认识的名称返回错误
+func TestParseHandicap(t *testing.T) {
+	for _, h := range Handicaps() {
+		got, err := ParseHandicap(h.String())
+		if err != nil || got != h {
+			t.Errorf("%v: got %v %v", h, got, err)
+		}
+	}
+	if _, err := ParseHandicap("让双车"); err == nil {
+		t.Error("unknown handicap: no error")
+	}
+}
+
+//TestHandicapSearch 每种让子的初始局面都能搜索出合法走法
+func TestHandicapSearch(t *testing.T) {
+	for _, h := range Handicaps() {
+		p := NewPosition()
+		p.SetHandicap(h)
+		if vs := p.Validate(); len(vs) > 0 {
+			t.Errorf("%v: invalid position %v", h, vs)
+			continue
+		}
+		if mv := p.Search(SearchLimits{Depth: 3}); !isLegal(p, mv) {
+			t.Errorf("%v: illegal move %v", h, mv)
+		}
+	}
+}
+
+//TestReset 从FEN开始的对局回到FEN局面，让子的对局回到让子的初始局面
+func TestReset(t *testing.T) {
+	const fen = "4k4/9/9/9/9/9/9/9/4R4/3K5 b - - 4 12"
+	p, err := NewPositionFromFEN(fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	playMoves(t, p, "e9f9", "e1d1")
+	p.Reset()
+	if got := p.FEN(); got != fen || len(p.Moves()) != 0 {
+		t.Errorf("reset from FEN: got %q after %d moves, want %q", got, len(p.Moves()), fen)
+	}
+
+	p.SetHandicap(HandicapJu)
+	fenHandicap := p.FEN()
+	playMoves(t, p, "h2e2")
+	p.Reset()
+	if got := p.FEN(); got != fenHandicap || p.Handicap() != HandicapJu {
+		t.Errorf("reset with handicap: got %q %v, want %q", got, p.Handicap(), fenHandicap)
+	}
+}
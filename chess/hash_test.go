@@ -0,0 +1,110 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 校验码和开局库测试
+ */
+
+package chess
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+//mirrorFEN 左右镜像的FEN串
+func mirrorFEN(fen string) string {
+	fields := strings.Fields(fen)
+	ranks := strings.Split(fields[0], "/")
+	for i, rank := range ranks {
+		runes := []rune(rank)
+		for l, r := 0, len(runes)-1; l < r; l, r = l+1, r-1 {
+			runes[l], runes[r] = runes[r], runes[l]
+		}
+		ranks[i] = string(runes)
+	}
+	fields[0] = strings.Join(ranks, "/")
+	return strings.Join(fields, " ")
+}
+
+//TestHashPlayUndo 走一步再撤消，校验码回到原来的值，FEN相同的局面校验码相同
+func TestHashPlayUndo(t *testing.T) {
+	p := NewPosition()
+	hashes := []uint64{p.Hash()}
+	for _, str := range []string{"h2e2", "h9g7", "h0g2", "i9h9", "i0h0", "b9c7"} {
+		playMoves(t, p, str)
+		hashes = append(hashes, p.Hash())
+		q, err := NewPositionFromFEN(p.FEN())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q.Hash() != p.Hash() {
+			t.Errorf("after %s: FEN hash %x, want %x", str, q.Hash(), p.Hash())
+		}
+	}
+	for i := len(hashes) - 1; i > 0; i-- {
+		if p.Hash() != hashes[i] {
+			t.Fatalf("before undo %d: hash %x, want %x", i, p.Hash(), hashes[i])
+		}
+		p.Undo()
+	}
+	if p.Hash() != hashes[0] {
+		t.Errorf("after undo: hash %x, want %x", p.Hash(), hashes[0])
+	}
+}
+
+//TestHashTransposition 不同次序走到同一局面，校验码相同，走子方不同校验码不同
+func TestHashTransposition(t *testing.T) {
+	p := NewPosition()
+	playMoves(t, p, "h2e2", "h9g7", "b0c2", "b9c7")
+	q := NewPosition()
+	playMoves(t, q, "b0c2", "b9c7", "h2e2", "h9g7")
+	if p.Hash() != q.Hash() {
+		t.Errorf("transposition: hash %x and %x", p.Hash(), q.Hash())
+	}
+
+	r, err := NewPositionFromFEN(strings.Replace(p.FEN(), " w ", " b ", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Hash() == p.Hash() {
+		t.Error("side to move does not change the hash")
+	}
+}
+
+//TestBookLock 镜像局面的开局库校验码和按镜像FEN摆出来的局面相同，开局和镜像的开局都能查到开局库
+func TestBookLock(t *testing.T) {
+	for _, moves := range [][]string{{}, {"h2e2"}, {"h2e2", "h9g7"}, {"b0c2", "h7e7", "h0g2"}} {
+		p := NewPosition()
+		playMoves(t, p, moves...)
+		q, err := NewPositionFromFEN(mirrorFEN(p.FEN()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := p.pos.mirrorLock(), q.pos.zobr.bookLock(); got != want {
+			t.Errorf("%v: mirror lock %x, want %x", moves, got, want)
+		}
+	}
+
+	//开局库按程序所在目录的相对路径加载
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(".."); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	p := NewPosition()
+	if !p.LoadBook() {
+		t.Fatal("cannot load the opening book")
+	}
+	for _, moves := range [][]string{{}, {"h2e2"}, {"b2e2"}, {"h2e2", "h9g7"}, {"b2e2", "b9c7"}} {
+		p.Reset()
+		playMoves(t, p, moves...)
+		if mv := p.pos.searchBook(); !isLegal(p, Move(mv)) {
+			t.Errorf("%v: book move %v", moves, Move(mv))
+		}
+	}
+}
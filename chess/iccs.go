@@ -0,0 +1,58 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * ICCS坐标记谱
+ */
+
+package chess
+
+import (
+	"fmt"
+	"strings"
+)
+
+//squareToICCS 把格子转换成ICCS坐标，例如"h2"
+func squareToICCS(sq int) string {
+	return string([]byte{byte('a' + getX(sq) - Left), byte('0' + Bottom - getY(sq))})
+}
+
+//iccsToSquare 把ICCS坐标转换成格子
+func iccsToSquare(str string) (int, error) {
+	if len(str) != 2 {
+		return 0, fmt.Errorf("iccs: invalid square %q", str)
+	}
+	c, r := str[0]|0x20, str[1]
+	if c < 'a' || c > 'i' || r < '0' || r > '9' {
+		return 0, fmt.Errorf("iccs: invalid square %q", str)
+	}
+	return squareXY(Left+int(c-'a'), Bottom-int(r-'0')), nil
+}
+
+//moveToICCS 把走法转换成ICCS串，例如"h2e2"
+func moveToICCS(mv int) string {
+	return squareToICCS(src(mv)) + squareToICCS(dst(mv))
+}
+
+//iccsToMove 把ICCS串转换成走法，兼容"H2-E2"的写法
+func iccsToMove(str string) (int, error) {
+	s := strings.TrimSpace(str)
+	if len(s) == 5 && s[2] == '-' {
+		s = s[:2] + s[3:]
+	}
+	if len(s) != 4 {
+		return 0, fmt.Errorf("iccs: invalid move %q", str)
+	}
+	sqSrc, err := iccsToSquare(s[:2])
+	if err != nil {
+		return 0, err
+	}
+	sqDst, err := iccsToSquare(s[2:])
+	if err != nil {
+		return 0, err
+	}
+	if sqSrc == sqDst {
+		return 0, fmt.Errorf("iccs: null move %q", str)
+	}
+	return move(sqSrc, sqDst), nil
+}
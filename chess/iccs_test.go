@@ -0,0 +1,62 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * ICCS坐标记谱测试
+ */
+
+package chess
+
+import (
+	"testing"
+)
+
+//TestICCSRoundTrip 棋盘上每个格子和每个走法转换成ICCS串再转换回来不变
+func TestICCSRoundTrip(t *testing.T) {
+	for sqSrc := 0; sqSrc < 256; sqSrc++ {
+		if !inBoard(sqSrc) {
+			continue
+		}
+		str := squareToICCS(sqSrc)
+		if sq, err := iccsToSquare(str); err != nil || sq != sqSrc {
+			t.Errorf("square %d: %q -> %d, %v", sqSrc, str, sq, err)
+		}
+		for sqDst := 0; sqDst < 256; sqDst++ {
+			if !inBoard(sqDst) || sqDst == sqSrc {
+				continue
+			}
+			mv := move(sqSrc, sqDst)
+			if mvParsed, err := iccsToMove(moveToICCS(mv)); err != nil || mvParsed != mv {
+				t.Errorf("move %q -> %d, %v", moveToICCS(mv), mvParsed, err)
+			}
+		}
+	}
+}
+
+//TestParseICCS 大小写、短横线和空白的写法，以及不合法的输入
+func TestParseICCS(t *testing.T) {
+	mvWant := move(squareXY(Left+7, Bottom-2), squareXY(Left+4, Bottom-2))
+	for _, str := range []string{"h2e2", "H2E2", "h2-e2", "H2-e2", "h2-E2", " h2e2\n"} {
+		if mv, err := iccsToMove(str); err != nil || mv != mvWant {
+			t.Errorf("%q: got %d, %v, want %d", str, mv, err, mvWant)
+		}
+	}
+
+	for _, str := range []string{
+		"",       //空串
+		"h2e",    //太短
+		"h2e2e2", //太长
+		"h2xe2",  //中间不是短横线
+		"h2--e2", //两个短横线
+		"j2e2",   //纵线超出棋盘
+		"h2`2",   //纵线在a之前
+		"h2e:",   //横线不是数字
+		"hae2",   //横线不是数字
+		"h2h2",   //起点和终点相同
+		"-h2e2",  //短横线位置不对
+	} {
+		if mv, err := iccsToMove(str); err == nil {
+			t.Errorf("%q: got %d, want error", str, mv)
+		}
+	}
+}
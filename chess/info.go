@@ -0,0 +1,95 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 搜索信息
+ */
+
+package chess
+
+import (
+	"time"
+)
+
+//hashFullSample 估计置换表占用率时抽查的表项数
+const hashFullSample = 1000
+
+//SearchInfo 搜索信息，每次迭代完成和找到新的最佳走法时报告
+type SearchInfo struct {
+	Depth    int           //迭代深度
+	Score    int           //最佳走法的分值，从走棋一方看，长将、长捉判负的分值在WinValue和BanValue之间
+	Mate     int           //几步(半回合)之后杀棋，负数表示被杀，IsMate为false时没有意义
+	IsMate   bool          //分值是否是杀棋分值，为true而Mate是0时表示走子方已经被杀
+	Nodes    int           //搜索的节点数
+	NPS      int           //每秒搜索的节点数
+	Time     time.Duration //搜索用时
+	HashFull int           //置换表占用率(千分之几)
+	BestMove Move          //最佳走法
+	PV       []Move        //主要变例，即预计的后续走法，从最佳走法开始
+	MultiPV  int           //多主要变例时的名次，从1开始
+}
+
+//hashFull 抽查置换表的前hashFullSample项估计占用率
+func (s *Search) hashFull() int {
+	nUsed := 0
+	for i := 0; i < hashFullSample && i < len(s.hashTable); i++ {
+		if s.hashTable[i].qwLock != 0 {
+			nUsed++
+		}
+	}
+	return nUsed * 1000 / hashFullSample
+}
+
+//newInfo 生成第nMultiPV名的搜索信息，最佳走法和主要变例取自根节点
+func (p *PositionStruct) newInfo(nDepth, nMultiPV, vl int) SearchInfo {
+	s := p.search
+	info := SearchInfo{
+		Depth:    nDepth,
+		Score:    vl,
+		Nodes:    s.nNodes,
+		Time:     time.Since(s.tStart),
+		HashFull: s.hashFull(),
+		PV:       make([]Move, s.nPVLength[0]),
+		MultiPV:  nMultiPV,
+	}
+	for i := range info.PV {
+		info.PV[i] = Move(s.mvsPV[0][i])
+	}
+	if len(info.PV) > 0 {
+		info.BestMove = info.PV[0]
+	}
+	//长将、长捉判负的分值不超过BanValue，不是杀棋
+	if vl > BanValue {
+		info.Mate, info.IsMate = MateValue-vl, true
+	} else if vl < -BanValue {
+		info.Mate, info.IsMate = -MateValue-vl, true
+	}
+	if info.Time > 0 {
+		info.NPS = int(float64(info.Nodes) / info.Time.Seconds())
+	}
+	return info
+}
+
+//singleInfo 不经过迭代加深就得出的搜索信息，只有最佳走法
+func (p *PositionStruct) singleInfo() SearchInfo {
+	info := SearchInfo{BestMove: Move(p.search.mvResult), MultiPV: 1}
+	if info.BestMove != 0 {
+		info.PV = []Move{info.BestMove}
+	}
+	return info
+}
+
+//reportInfo 把搜索信息报告给回调函数
+func (p *PositionStruct) reportInfo(info SearchInfo) {
+	if p.search.onInfo != nil {
+		p.search.onInfo(info)
+	}
+}
+
+//updatePV 走法mv成为当前节点的最佳走法，主要变例是mv接上子节点的主要变例
+func (p *PositionStruct) updatePV(mv int) {
+	s := p.search
+	s.mvsPV[p.nDistance][0] = mv
+	n := copy(s.mvsPV[p.nDistance][1:], s.mvsPV[p.nDistance+1][:s.nPVLength[p.nDistance+1]])
+	s.nPVLength[p.nDistance] = n + 1
+}
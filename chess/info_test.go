@@ -0,0 +1,72 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 搜索信息测试
+ */
+
+package chess
+
+import (
+	"testing"
+)
+
+//TestMatedAtRoot 走子方已经被杀时报告0步杀棋，和没有找到杀棋区分开
+func TestMatedAtRoot(t *testing.T) {
+	p, err := NewPositionFromFEN("3kr4/9/9/9/9/9/9/6n2/3r5/4K4 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := p.Analyze(SearchLimits{Depth: 3})
+	if !info.IsMate || info.Mate != 0 || info.Score != -MateValue {
+		t.Errorf("mated: IsMate %v, Mate %d, Score %d", info.IsMate, info.Mate, info.Score)
+	}
+	if info.BestMove != 0 || len(info.PV) != 0 {
+		t.Errorf("mated: best move %v, PV %v", info.BestMove, info.PV)
+	}
+
+	//杀一步
+	p, err = NewPositionFromFEN("3k5/8r/9/9/9/9/9/6n2/3r5/4K4 b - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info = p.Analyze(SearchLimits{Depth: 3})
+	if !info.IsMate || info.Mate != 1 {
+		t.Errorf("mate in 1: IsMate %v, Mate %d, best move %v", info.IsMate, info.Mate, info.BestMove)
+	}
+
+	//没有杀棋
+	info = NewPosition().Analyze(SearchLimits{Depth: 3})
+	if info.IsMate || info.Mate != 0 {
+		t.Errorf("startpos: IsMate %v, Mate %d", info.IsMate, info.Mate)
+	}
+}
+
+//TestMoveString 没有走法和不合法的走法不会转换成乱码
+func TestMoveString(t *testing.T) {
+	cases := []struct {
+		mv   Move
+		want string
+	}{
+		{0, "(none)"},
+		{-1, "(none)"},
+		{0x10000, "(none)"},
+		{NewMove(0, 0), "(none)"},
+		{NewMove(SquareXY(Left+7, Bottom-2), SquareXY(Left+4, Bottom-2)), "h2e2"},
+	}
+	for _, c := range cases {
+		if got := c.mv.String(); got != c.want {
+			t.Errorf("Move(%#x).String() = %q, want %q", int(c.mv), got, c.want)
+		}
+	}
+}
+
+//TestPerpetualCheckScore 长将判负的分值超过WinValue，但不是杀棋
+func TestPerpetualCheckScore(t *testing.T) {
+	//红方不将军就会被黑车杀，只能长将
+	p := playCycle(t, "3k5/R8/9/9/9/9/9/7r1/8r/4K4 w - - 0 1", []string{"a8a9", "d9d8", "a9a8", "d8d9"}, 1)
+	info := p.Analyze(SearchLimits{Depth: 5})
+	if info.Score >= -WinValue || info.IsMate {
+		t.Errorf("perpetual check: Score %d, IsMate %v, Mate %d, best move %v", info.Score, info.IsMate, info.Mate, info.BestMove)
+	}
+}
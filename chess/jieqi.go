@@ -0,0 +1,614 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 揭棋
+ */
+
+package chess
+
+import (
+	"context"
+	"math/rand"
+)
+
+//揭棋规则：
+//1. 除了帅(将)以外，双方棋子打乱以后背面朝上放在初始位置上；
+//2. 暗子按所在格子原来的棋子走，走完第一步就翻开，以后按翻开的棋子走；
+//3. 翻开的仕(士)、相(象)不受九宫和河界的限制；
+//4. 无棋可走的一方判负，同一局面出现三次或连续JieqiDrawMoves回合没有吃子也没有走暗子判和。
+//ucpcSquares里暗子放的是所在格子原来的棋子，所以原有的走法生成可以直接用；
+//暗子的真实身份只有裁判(Play)知道，搜索时按暗子池里各种棋子的比例计算期望值。
+
+//JieqiDrawMoves 连续多少回合没有吃子也没有走暗子判和
+const JieqiDrawMoves = 60
+
+//ccJieqiValue 揭棋子力价值，顺序同PieceJiang...PieceBing，翻开的仕(士)、相(象)不受限制，比普通象棋值钱
+var ccJieqiValue = [7]int{0, 200, 200, 400, 900, 450, 100}
+
+//jieqiUndo 撤消揭棋走法需要的信息
+type jieqiUndo struct {
+	mv          int    //走法
+	pcMoved     int    //走之前的棋子，暗子是所在格子原来的棋子
+	pcCaptured  int    //被吃的棋子，暗子是所在格子原来的棋子
+	bSrcHidden  bool   //走的是否是暗子
+	bRevealed   bool   //是否翻开了暗子
+	bCapHidden  bool   //是否吃了暗子
+	ptRevealed  int    //翻开的棋子
+	ptCapHidden int    //吃掉的暗子的真实身份，搜索时不知道为-1
+	qwKey       uint64 //走之前局面的校验码
+}
+
+//Jieqi 揭棋对局
+type Jieqi struct {
+	pos          *PositionStruct //棋盘，暗子放的是所在格子原来的棋子
+	bHidden      [256]bool       //格子上是否是暗子
+	ucpcIdentity [256]int        //暗子的真实身份，只有裁判知道，搜索不能用
+	nPool        [2][7]int       //每方还没翻开的暗子里各种棋子的数量
+	undos        []jieqiUndo     //撤消信息
+	mvs          []Move          //走过的棋
+	nDistance    int             //距离根节点的步数
+	mvResult     int             //电脑走的棋
+	searchClock                  //搜索限制
+}
+
+//NewJieqi 创建揭棋对局，相同的seed打乱的结果相同
+func NewJieqi(seed int64) *Jieqi {
+	j := &Jieqi{
+		pos: NewPositionStruct(),
+	}
+	j.Reset(seed)
+	return j
+}
+
+//Reset 重新开始，用seed打乱暗子
+func (j *Jieqi) Reset(seed int64) {
+	rnd := rand.New(rand.NewSource(seed))
+	j.pos.startup()
+	j.bHidden = [256]bool{}
+	j.ucpcIdentity = [256]int{}
+	j.nPool = [2][7]int{}
+	j.undos = j.undos[:0]
+	j.mvs = j.mvs[:0]
+	j.nDistance = 0
+
+	for sd := 0; sd < 2; sd++ {
+		pcSelfSide := sideTag(sd)
+		sqs, pcs := []int{}, []int{}
+		for sq := 0; sq < 256; sq++ {
+			pc := cucpcStartup[sq]
+			if (pc&pcSelfSide) == 0 || pc-pcSelfSide == PieceJiang {
+				continue
+			}
+			sqs = append(sqs, sq)
+			pcs = append(pcs, pc)
+			j.nPool[sd][pc-pcSelfSide]++
+		}
+		rnd.Shuffle(len(pcs), func(a, b int) {
+			pcs[a], pcs[b] = pcs[b], pcs[a]
+		})
+		for i, sq := range sqs {
+			j.bHidden[sq] = true
+			j.ucpcIdentity[sq] = pcs[i]
+		}
+	}
+}
+
+//generateMoves 生成揭棋走法，翻开的仕(士)、相(象)另外生成
+func (j *Jieqi) generateMoves(mvs []int, bCapture bool) int {
+	p := j.pos
+	pcSelfSide := sideTag(p.sdPlayer)
+	pcOppSide := oppSideTag(p.sdPlayer)
+
+	//去掉翻开的仕(士)、相(象)受限制的走法
+	nGenMoves := 0
+	n := p.generateMoves(mvs, bCapture)
+	for i := 0; i < n; i++ {
+		sqSrc := src(mvs[i])
+		pt := p.ucpcSquares[sqSrc] - pcSelfSide
+		if (pt == PieceShi || pt == PieceXiang) && !j.bHidden[sqSrc] {
+			continue
+		}
+		mvs[nGenMoves] = mvs[i]
+		nGenMoves++
+	}
+
+	for _, pt := range [...]int{PieceShi, PieceXiang} {
+		for bb := p.bbPieces[p.sdPlayer][pt]; !bb.isEmpty(); {
+			sqSrc := ccSquare256[bb.pop()]
+			if j.bHidden[sqSrc] {
+				continue
+			}
+			for i := 0; i < 4; i++ {
+				nDelta := ccShiDelta[i]
+				sqDst := sqSrc + nDelta
+				//相(象)眼不能有棋子
+				if pt == PieceXiang {
+					if !inBoard(sqDst) || p.ucpcSquares[sqDst] != 0 {
+						continue
+					}
+					sqDst += nDelta
+				}
+				if !inBoard(sqDst) {
+					continue
+				}
+				pcDst := p.ucpcSquares[sqDst]
+				if (bCapture && (pcDst&pcOppSide) != 0) || (!bCapture && (pcDst&pcSelfSide) == 0) {
+					mvs[nGenMoves] = move(sqSrc, sqDst)
+					nGenMoves++
+				}
+			}
+		}
+	}
+	return nGenMoves
+}
+
+//checked 判断是否被将军，翻开的仕(士)、相(象)可以过河，也能将军
+func (j *Jieqi) checked() bool {
+	p := j.pos
+	if p.checked() {
+		return true
+	}
+	pcOppSide := oppSideTag(p.sdPlayer)
+	for bb := p.bbPieces[p.sdPlayer][PieceJiang]; !bb.isEmpty(); {
+		sqSrc := ccSquare256[bb.pop()]
+		for i := 0; i < 4; i++ {
+			nDelta := ccShiDelta[i]
+			pcDst := p.ucpcSquares[sqSrc+nDelta]
+			if pcDst == pcOppSide+PieceShi {
+				return true
+			}
+			if pcDst == 0 && p.ucpcSquares[sqSrc+nDelta*2] == pcOppSide+PieceXiang {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+//undoMovePiece 撤消搬一步棋的棋子
+func (j *Jieqi) undoMovePiece(u *jieqiUndo) {
+	p := j.pos
+	sqSrc := src(u.mv)
+	sqDst := dst(u.mv)
+	p.delPiece(sqDst, p.ucpcSquares[sqDst])
+	p.addPiece(sqSrc, u.pcMoved)
+	if u.pcCaptured != 0 {
+		p.addPiece(sqDst, u.pcCaptured)
+	}
+	j.bHidden[sqSrc] = u.bSrcHidden
+	j.bHidden[sqDst] = u.bCapHidden
+}
+
+//makeMove 走一步棋，暗子翻开成ptRevealed(为-1时不翻开)，吃掉的暗子是ptCapHidden(不知道时为-1)
+func (j *Jieqi) makeMove(mv, ptRevealed, ptCapHidden int) bool {
+	p := j.pos
+	sqSrc := src(mv)
+	sqDst := dst(mv)
+	u := jieqiUndo{
+		mv:          mv,
+		pcMoved:     p.ucpcSquares[sqSrc],
+		pcCaptured:  p.ucpcSquares[sqDst],
+		bSrcHidden:  j.bHidden[sqSrc],
+		bRevealed:   j.bHidden[sqSrc] && ptRevealed >= 0,
+		bCapHidden:  j.bHidden[sqDst],
+		ptRevealed:  ptRevealed,
+		ptCapHidden: ptCapHidden,
+		qwKey:       p.zobr.qwKey,
+	}
+	pc := u.pcMoved
+	if u.bRevealed {
+		pc = sideTag(p.sdPlayer) + ptRevealed
+	}
+	if u.pcCaptured != 0 {
+		p.delPiece(sqDst, u.pcCaptured)
+	}
+	p.delPiece(sqSrc, u.pcMoved)
+	p.addPiece(sqDst, pc)
+	j.bHidden[sqSrc] = false
+	j.bHidden[sqDst] = u.bSrcHidden && !u.bRevealed
+	if j.checked() {
+		j.undoMovePiece(&u)
+		return false
+	}
+
+	//翻开的棋子和已知身份的暗子从暗子池里拿掉
+	if u.bRevealed {
+		j.nPool[p.sdPlayer][ptRevealed]--
+	}
+	if u.bCapHidden && ptCapHidden >= 0 {
+		j.nPool[1-p.sdPlayer][ptCapHidden]--
+	}
+	p.changeSide()
+	j.undos = append(j.undos, u)
+	j.nDistance++
+	return true
+}
+
+//undoMakeMove 撤消走一步棋
+func (j *Jieqi) undoMakeMove() {
+	p := j.pos
+	u := j.undos[len(j.undos)-1]
+	j.undos = j.undos[:len(j.undos)-1]
+	j.nDistance--
+	p.changeSide()
+	if u.bRevealed {
+		j.nPool[p.sdPlayer][u.ptRevealed]++
+	}
+	if u.bCapHidden && u.ptCapHidden >= 0 {
+		j.nPool[1-p.sdPlayer][u.ptCapHidden]++
+	}
+	j.undoMovePiece(&u)
+}
+
+//noProgressMoves 上一次吃子或走暗子之后走了多少步
+func (j *Jieqi) noProgressMoves() int {
+	nMoves := 0
+	for i := len(j.undos) - 1; i >= 0 && !j.undos[i].bSrcHidden && j.undos[i].pcCaptured == 0; i-- {
+		nMoves++
+	}
+	return nMoves
+}
+
+//repetitions 当前局面在上一次吃子或走暗子之后重复出现过几次
+func (j *Jieqi) repetitions() int {
+	nRecur := 0
+	for i := len(j.undos) - 1; i >= 0 && !j.undos[i].bSrcHidden && j.undos[i].pcCaptured == 0; i-- {
+		if j.undos[i].qwKey == j.pos.zobr.qwKey {
+			nRecur++
+		}
+	}
+	return nRecur
+}
+
+//hiddenValue 一方暗子的期望价值
+func (j *Jieqi) hiddenValue(sd int) int {
+	nTotal, vlTotal := 0, 0
+	for pt := PieceShi; pt <= PieceBing; pt++ {
+		nTotal += j.nPool[sd][pt]
+		vlTotal += j.nPool[sd][pt] * ccJieqiValue[pt]
+	}
+	if nTotal == 0 {
+		return 0
+	}
+	return vlTotal / nTotal
+}
+
+//pieceValue 格子上棋子的价值，暗子按期望价值算
+func (j *Jieqi) pieceValue(sq int) int {
+	pc := j.pos.ucpcSquares[sq]
+	sd := 0
+	if pc >= 16 {
+		sd = 1
+	}
+	if j.bHidden[sq] {
+		return j.hiddenValue(sd)
+	}
+	vl := ccJieqiValue[pc&7]
+	//过河兵(卒)价值翻倍
+	if pc&7 == PieceBing && hasRiver(sq, sd) {
+		vl *= 2
+	}
+	return vl
+}
+
+//evaluate 局面评价函数
+func (j *Jieqi) evaluate() int {
+	vl := [2]int{}
+	for sd := 0; sd < 2; sd++ {
+		for bb := j.pos.bbSides[sd]; !bb.isEmpty(); {
+			vl[sd] += j.pieceValue(ccSquare256[bb.pop()])
+		}
+	}
+	return vl[j.pos.sdPlayer] - vl[1-j.pos.sdPlayer] + AdvancedValue
+}
+
+//sortMoves 生成走法并按被吃棋子的价值排序，mvFirst排在最前面
+func (j *Jieqi) sortMoves(bCapture bool, mvFirst int) []int {
+	mvs := make([]int, MaxGenMoves)
+	mvs = mvs[:j.generateMoves(mvs, bCapture)]
+	vls := make([]int, len(mvs))
+	for i, mv := range mvs {
+		if mv == mvFirst {
+			vls[i] = MateValue
+		} else if j.pos.ucpcSquares[dst(mv)] != 0 {
+			vls[i] = j.pieceValue(dst(mv))
+		}
+	}
+	//插入排序，走法不多
+	for i := 1; i < len(mvs); i++ {
+		mv, vl := mvs[i], vls[i]
+		k := i
+		for ; k > 0 && vls[k-1] < vl; k-- {
+			mvs[k], vls[k] = mvs[k-1], vls[k-1]
+		}
+		mvs[k], vls[k] = mv, vl
+	}
+	return mvs
+}
+
+//searchMove 走一步棋并搜索，返回对走子方的分值，走法不合法时返回false
+//翻开暗子是机会节点，按暗子池里各种棋子的比例取期望值；吃掉的暗子身份不明，不影响暗子池的比例
+func (j *Jieqi) searchMove(mv, vlAlpha, vlBeta, nDepth int) (int, bool) {
+	//叶子节点上暗子走完不翻开，仍然按暗子的期望价值算，相当于对子力取期望
+	sqSrc := src(mv)
+	if !j.bHidden[sqSrc] || nDepth <= 0 {
+		if !j.makeMove(mv, -1, -1) {
+			return 0, false
+		}
+		vl := -j.searchFull(-vlBeta, -vlAlpha, nDepth)
+		j.undoMakeMove()
+		return vl, true
+	}
+
+	sd := j.pos.sdPlayer
+	nTotal := 0
+	for pt := PieceShi; pt <= PieceBing; pt++ {
+		nTotal += j.nPool[sd][pt]
+	}
+	if nTotal == 0 {
+		return 0, false
+	}
+
+	//Star1裁剪：还没搜索的分支按最高分和最低分估计，期望值已经超出窗口就不用再搜
+	nRest, vlSum := nTotal, 0
+	for pt := PieceShi; pt <= PieceBing; pt++ {
+		n := j.nPool[sd][pt]
+		if n == 0 {
+			continue
+		}
+		nRest -= n
+		vlChildAlpha := (vlAlpha*nTotal - vlSum - MateValue*nRest) / n
+		if vlChildAlpha < -MateValue {
+			vlChildAlpha = -MateValue
+		}
+		vlChildBeta := (vlBeta*nTotal - vlSum + MateValue*nRest) / n
+		if vlChildBeta > MateValue {
+			vlChildBeta = MateValue
+		}
+
+		//走法是否合法和翻开的棋子无关
+		if !j.makeMove(mv, pt, -1) {
+			return 0, false
+		}
+		vl := -j.searchFull(-vlChildBeta, -vlChildAlpha, nDepth)
+		j.undoMakeMove()
+
+		vlSum += n * vl
+		if vl <= vlChildAlpha {
+			return (vlSum + MateValue*nRest) / nTotal, true
+		}
+		if vl >= vlChildBeta {
+			return (vlSum - MateValue*nRest) / nTotal, true
+		}
+	}
+	return vlSum / nTotal, true
+}
+
+//searchQuiesc 静态(Quiescence)搜索过程，只搜索明子的吃子走法，将军和杀棋交给完全搜索
+func (j *Jieqi) searchQuiesc(vlAlpha, vlBeta int) int {
+	if j.stopped() {
+		return 0
+	}
+	vlBest := j.evaluate()
+	if vlBest >= vlBeta || j.nDistance >= LimitDepth {
+		return vlBest
+	}
+	if vlBest > vlAlpha {
+		vlAlpha = vlBest
+	}
+
+	for _, mv := range j.sortMoves(true, 0) {
+		//暗子吃子要展开机会节点，分支太多，静态搜索里不走
+		if j.bHidden[src(mv)] {
+			continue
+		}
+		vl, ok := j.searchMove(mv, vlAlpha, vlBeta, 0)
+		if !ok {
+			continue
+		}
+		if vl > vlBest {
+			if vl >= vlBeta {
+				return vl
+			}
+			vlBest = vl
+			if vl > vlAlpha {
+				vlAlpha = vl
+			}
+		}
+	}
+	return vlBest
+}
+
+//searchFull 超出边界(Fail-Soft)的Alpha-Beta搜索过程，根节点记录最佳走法
+func (j *Jieqi) searchFull(vlAlpha, vlBeta, nDepth int) int {
+	//重复局面和走满步数的局面都按和棋算，电脑不会来回走
+	if j.nDistance > 0 && (j.repetitions() > 0 || j.noProgressMoves() >= JieqiDrawMoves*2) {
+		return 0
+	}
+	if nDepth <= 0 {
+		return j.searchQuiesc(vlAlpha, vlBeta)
+	}
+	if j.stopped() {
+		return 0
+	}
+
+	mvFirst := 0
+	if j.nDistance == 0 {
+		mvFirst = j.mvResult
+	}
+	vlBest, mvBest := -MateValue, 0
+	for _, mv := range j.sortMoves(false, mvFirst) {
+		vl, ok := j.searchMove(mv, vlAlpha, vlBeta, nDepth-1)
+		if !ok {
+			continue
+		}
+		if vl > vlBest {
+			vlBest = vl
+			mvBest = mv
+			if vl >= vlBeta {
+				break
+			}
+			if vl > vlAlpha {
+				vlAlpha = vl
+			}
+		}
+	}
+
+	if vlBest == -MateValue {
+		return j.nDistance - MateValue
+	}
+	//超出搜索限制的那一层搜索结果不完整，不能用
+	if j.nDistance == 0 && !j.bStop {
+		j.mvResult = mvBest
+	}
+	return vlBest
+}
+
+//searchMain 迭代加深搜索过程，超出limits或ctx取消时停止
+func (j *Jieqi) searchMain(ctx context.Context, limits SearchLimits) {
+	j.startClock(ctx, limits, j.pos.sdPlayer)
+	j.nDistance = 0
+	j.mvResult = 0
+	for i := 1; i <= limits.maxDepth(); i++ {
+		vl := j.searchFull(-MateValue, MateValue, i)
+		//超出搜索限制或被取消，或者搜索到杀棋(无限分析时继续)，就终止搜索
+		if j.bStop || (!limits.Infinite && (vl > WinValue || vl < -WinValue)) {
+			break
+		}
+	}
+	j.waitCancel()
+	//第一层都没有搜完，随便走一步合法的棋
+	if j.mvResult == 0 {
+		if mvs := j.LegalMoves(); len(mvs) > 0 {
+			j.mvResult = int(mvs[0])
+		}
+	}
+}
+
+//Side 轮到谁走
+func (j *Jieqi) Side() int {
+	return j.pos.sdPlayer
+}
+
+//Piece 格子上的棋子，暗子返回所在格子原来的棋子
+func (j *Jieqi) Piece(sq int) int {
+	if sq < 0 || sq > 255 {
+		return 0
+	}
+	return j.pos.ucpcSquares[sq]
+}
+
+//Hidden 格子上是否是暗子
+func (j *Jieqi) Hidden(sq int) bool {
+	return sq >= 0 && sq < 256 && j.bHidden[sq]
+}
+
+//Moves 走过的棋
+func (j *Jieqi) Moves() []Move {
+	return append([]Move{}, j.mvs...)
+}
+
+//LegalMoves 走子方所有合法走法
+func (j *Jieqi) LegalMoves() []Move {
+	result := []Move{}
+	mvs := make([]int, MaxGenMoves)
+	nGenMoves := j.generateMoves(mvs, false)
+	for i := 0; i < nGenMoves; i++ {
+		if j.makeMove(mvs[i], -1, -1) {
+			j.undoMakeMove()
+			result = append(result, Move(mvs[i]))
+		}
+	}
+	j.nDistance = 0
+	return result
+}
+
+//Play 走一步棋，暗子按真实身份翻开
+func (j *Jieqi) Play(mv Move) error {
+	if j.Result().IsOver() {
+		return ErrGameOver
+	}
+	if mv <= 0 || mv > 0xffff || !inBoard(mv.Src()) || !inBoard(mv.Dst()) {
+		return ErrIllegalMove
+	}
+	mvs := make([]int, MaxGenMoves)
+	nGenMoves := j.generateMoves(mvs, false)
+	bFound := false
+	for i := 0; i < nGenMoves; i++ {
+		if mvs[i] == int(mv) {
+			bFound = true
+			break
+		}
+	}
+	if !bFound {
+		return ErrIllegalMove
+	}
+
+	sqSrc := mv.Src()
+	sqDst := mv.Dst()
+	ptRevealed, ptCapHidden := 0, -1
+	if j.bHidden[sqSrc] {
+		ptRevealed = j.ucpcIdentity[sqSrc] & 7
+	}
+	if j.bHidden[sqDst] {
+		ptCapHidden = j.ucpcIdentity[sqDst] & 7
+	}
+	if !j.makeMove(int(mv), ptRevealed, ptCapHidden) {
+		return ErrSelfCheck
+	}
+	j.nDistance = 0
+	j.mvs = append(j.mvs, mv)
+	return nil
+}
+
+//Undo 撤消上一步棋
+func (j *Jieqi) Undo() bool {
+	if len(j.mvs) == 0 {
+		return false
+	}
+	j.mvs = j.mvs[:len(j.mvs)-1]
+	j.undoMakeMove()
+	j.nDistance = 0
+	return true
+}
+
+//IsCheck 走子方是否被将军
+func (j *Jieqi) IsCheck() bool {
+	return j.checked()
+}
+
+//Result 对局结果，无棋可走的一方判负，同一局面出现三次或连续JieqiDrawMoves回合没有吃子也没有走暗子判和
+func (j *Jieqi) Result() Result {
+	if len(j.LegalMoves()) == 0 {
+		if j.checked() {
+			return winResult(1-j.pos.sdPlayer, ReasonCheckmate)
+		}
+		return winResult(1-j.pos.sdPlayer, ReasonStalemate)
+	}
+	if j.repetitions() >= 2 {
+		return drawResult(ReasonRepetition)
+	}
+	if j.noProgressMoves() >= JieqiDrawMoves*2 {
+		return drawResult(ReasonMoveLimit)
+	}
+	return Result{}
+}
+
+//BestMove 电脑思考DefaultMoveTime搜索出的最佳走法，电脑不知道暗子的真实身份，没有走法时返回0
+func (j *Jieqi) BestMove() Move {
+	return j.Search(SearchLimits{})
+}
+
+//Search 在limits限制内搜索最佳走法，没有走法时返回0
+func (j *Jieqi) Search(limits SearchLimits) Move {
+	return j.SearchContext(context.Background(), limits)
+}
+
+//SearchContext 在limits限制内搜索最佳走法，ctx取消时停止，返回最后一次完整迭代的最佳走法
+func (j *Jieqi) SearchContext(ctx context.Context, limits SearchLimits) Move {
+	j.searchMain(ctx, limits)
+	j.nDistance = 0
+	return Move(j.mvResult)
+}
@@ -0,0 +1,73 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 揭棋测试
+ */
+
+package chess
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+//isJieqiLegal 走法是否在揭棋的合法走法里
+func isJieqiLegal(j *Jieqi, mv Move) bool {
+	for _, mvLegal := range j.LegalMoves() {
+		if mvLegal == mv {
+			return true
+		}
+	}
+	return false
+}
+
+//TestJieqiSearchLimits 揭棋搜索也按SearchLimits限制，ctx取消时停止
+func TestJieqiSearchLimits(t *testing.T) {
+	j := NewJieqi(1)
+	if mv := j.Search(SearchLimits{Depth: 2}); !isJieqiLegal(j, mv) {
+		t.Errorf("depth 2: illegal move %v", mv)
+	}
+	if mv := j.Search(SearchLimits{Nodes: 1000}); !isJieqiLegal(j, mv) || j.nNodes > 1000 {
+		t.Errorf("nodes 1000: move %v after %d nodes", mv, j.nNodes)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	tStart := time.Now()
+	mv := j.SearchContext(ctx, SearchLimits{Infinite: true})
+	if d := time.Since(tStart); d > time.Second {
+		t.Errorf("infinite search took %v after cancel", d)
+	}
+	if !isJieqiLegal(j, mv) {
+		t.Errorf("infinite: illegal move %v", mv)
+	}
+}
+
+//TestJieqiRepetition 揭棋同一局面出现三次判和，判和以后不能再走
+func TestJieqiRepetition(t *testing.T) {
+	j := NewJieqi(1)
+	strs := []string{"e0e1", "e9e8", "e1e0", "e8e9"}
+	for i := 0; i < 2; i++ {
+		if r := j.Result(); r.IsOver() {
+			t.Fatalf("round %d: game over early: %v", i, r)
+		}
+		for _, str := range strs {
+			mv, err := ParseMove(str)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := j.Play(mv); err != nil {
+				t.Fatalf("%s: %v", str, err)
+			}
+		}
+	}
+	if r := j.Result(); r != drawResult(ReasonRepetition) {
+		t.Errorf("got %v, want draw by repetition", r)
+	}
+	mv, _ := ParseMove("e0e1")
+	if err := j.Play(mv); err != ErrGameOver {
+		t.Errorf("play after draw: got %v, want %v", err, ErrGameOver)
+	}
+}
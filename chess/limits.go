@@ -0,0 +1,142 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 搜索限制
+ */
+
+package chess
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	//DefaultMoveTime 没有任何搜索限制时每步的思考时间
+	DefaultMoveTime = time.Second
+	//DefaultMovesToGo 按局时分配时间但没有给出MovesToGo时，假定还要走的步数
+	DefaultMovesToGo = 30
+	//checkNodes 每搜索这么多节点检查一次时间
+	checkNodes = 1024
+)
+
+//SearchLimits 搜索限制，为零的项表示不限制，都为零时每步思考DefaultMoveTime，无限分析时忽略其他限制
+type SearchLimits struct {
+	Infinite  bool          //无限分析，一直搜索到被取消
+	Depth     int           //最大搜索深度
+	Nodes     int           //最多搜索的节点数
+	MoveTime  time.Duration //每步的思考时间
+	WTime     time.Duration //红方的剩余局时
+	BTime     time.Duration //黑方的剩余局时
+	WInc      time.Duration //红方每步的加时
+	BInc      time.Duration //黑方每步的加时
+	MovesToGo int           //到下一次加时还要走的步数，0表示按DefaultMovesToGo算
+}
+
+//maxDepth 最大搜索深度，不超过LimitDepth
+func (l SearchLimits) maxDepth() int {
+	if !l.Infinite && l.Depth > 0 && l.Depth < LimitDepth {
+		return l.Depth
+	}
+	return LimitDepth
+}
+
+//moveTime 轮到sd走时的思考时间，0表示不限时间
+func (l SearchLimits) moveTime(sd int) time.Duration {
+	if l.Infinite {
+		return 0
+	}
+	if l.MoveTime > 0 {
+		return l.MoveTime
+	}
+
+	//按剩余局时和加时分配，最多用掉剩余局时的一半
+	tLeft, tInc := l.WTime, l.WInc
+	if sd == Black {
+		tLeft, tInc = l.BTime, l.BInc
+	}
+	if tLeft > 0 {
+		nMovesToGo := l.MovesToGo
+		if nMovesToGo <= 0 {
+			nMovesToGo = DefaultMovesToGo
+		}
+		t := tLeft/time.Duration(nMovesToGo) + tInc
+		if t > tLeft/2 {
+			t = tLeft / 2
+		}
+		return t
+	}
+
+	//给了局时或加时，但走子方没有剩余局时，只用加时思考，没有加时就思考DefaultMoveTime
+	if l.WTime > 0 || l.BTime > 0 || l.WInc > 0 || l.BInc > 0 || l.MovesToGo > 0 {
+		if tInc > 0 {
+			return tInc
+		}
+		return DefaultMoveTime
+	}
+	//只限制深度或节点数时不限时间
+	if l == (SearchLimits{}) {
+		return DefaultMoveTime
+	}
+	return 0
+}
+
+//searchClock 按搜索限制计时和计数，象棋、揭棋和翻翻棋的搜索共用
+type searchClock struct {
+	ctx    context.Context //取消搜索
+	limits SearchLimits    //搜索限制
+	tStart time.Time       //开始搜索的时间
+	tMove  time.Duration   //这一步的思考时间，0表示不限时间
+	nNodes int             //搜索的节点数
+	bStop  bool            //是否超出搜索限制
+}
+
+//startClock 轮到sd走，开始按limits限制搜索，ctx取消时停止
+func (c *searchClock) startClock(ctx context.Context, limits SearchLimits, sd int) {
+	c.ctx = ctx
+	c.limits = limits
+	c.tStart = time.Now()
+	c.tMove = limits.moveTime(sd)
+	c.nNodes = 0
+	c.bStop = false
+}
+
+//stopped 搜索一个节点，并判断是否超出了搜索限制
+func (c *searchClock) stopped() bool {
+	if c.bStop {
+		return true
+	}
+	c.nNodes++
+	if !c.limits.Infinite && c.limits.Nodes > 0 && c.nNodes >= c.limits.Nodes {
+		c.bStop = true
+	} else if c.nNodes%checkNodes == 0 {
+		//检查时间和是否被取消
+		if c.tMove > 0 && time.Since(c.tStart) >= c.tMove {
+			c.bStop = true
+		}
+		select {
+		case <-c.ctx.Done():
+			c.bStop = true
+		default:
+		}
+	}
+	return c.bStop
+}
+
+//waitCancel 无限分析到了极限深度，也要等到被取消才返回
+func (c *searchClock) waitCancel() {
+	if c.limits.Infinite && !c.bStop {
+		<-c.ctx.Done()
+	}
+}
+
+//startLimits 开始按limits限制搜索，ctx取消时停止
+func (p *PositionStruct) startLimits(ctx context.Context, limits SearchLimits) {
+	p.search.startClock(ctx, limits, p.sdPlayer)
+}
+
+//stopped 搜索一个节点，并判断是否超出了搜索限制
+func (p *PositionStruct) stopped() bool {
+	return p.search.stopped()
+}
@@ -0,0 +1,56 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 搜索限制测试
+ */
+
+package chess
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+//TestMoveTime 按搜索限制分配每步的思考时间，0表示不限时间
+func TestMoveTime(t *testing.T) {
+	cases := []struct {
+		name   string
+		limits SearchLimits
+		sd     int
+		want   time.Duration
+	}{
+		{"no limits", SearchLimits{}, Red, DefaultMoveTime},
+		{"infinite", SearchLimits{Infinite: true, MoveTime: time.Second}, Red, 0},
+		{"depth only", SearchLimits{Depth: 5}, Red, 0},
+		{"nodes only", SearchLimits{Nodes: 1000}, Black, 0},
+		{"move time", SearchLimits{MoveTime: 3 * time.Second, WTime: time.Minute}, Red, 3 * time.Second},
+		{"clock", SearchLimits{WTime: 60 * time.Second, WInc: time.Second, MovesToGo: 20}, Red, 4 * time.Second},
+		{"clock half left", SearchLimits{BTime: 2 * time.Second, BInc: 5 * time.Second}, Black, time.Second},
+		{"increments only", SearchLimits{WInc: time.Second, BInc: 2 * time.Second}, Black, 2 * time.Second},
+		{"other side clock", SearchLimits{WTime: time.Minute}, Black, DefaultMoveTime},
+		{"other side increment", SearchLimits{WInc: time.Second}, Black, DefaultMoveTime},
+		{"moves to go only", SearchLimits{MovesToGo: 10, Depth: 5}, Red, DefaultMoveTime},
+	}
+	for _, c := range cases {
+		if got := c.limits.moveTime(c.sd); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+//TestSearchIncrementsOnly 只给加时的搜索按加时停止，不会一直搜索到ctx取消
+func TestSearchIncrementsOnly(t *testing.T) {
+	p := NewPosition()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	tStart := time.Now()
+	mv := p.SearchContext(ctx, SearchLimits{WInc: 100 * time.Millisecond, BInc: 100 * time.Millisecond})
+	if d := time.Since(tStart); d > 2*time.Second {
+		t.Errorf("search took %v", d)
+	}
+	if mv == 0 {
+		t.Error("no best move")
+	}
+}
@@ -0,0 +1,276 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 纵线记谱(中文记谱)
+ */
+
+package chess
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//ccPieceName 中文棋子名称，顺序同PieceJiang...PieceBing
+var ccPieceName = [2][7]rune{
+	{'帅', '仕', '相', '马', '车', '炮', '兵'},
+	{'将', '士', '象', '马', '车', '炮', '卒'}}
+
+//ccChineseDigit 红方使用的中文数字
+var ccChineseDigit = [10]rune{'零', '一', '二', '三', '四', '五', '六', '七', '八', '九'}
+
+//ccPieceAlias 中文棋子名称的异体字
+var ccPieceAlias = map[rune]int{
+	'帅': PieceJiang, '帥': PieceJiang, '将': PieceJiang, '將': PieceJiang,
+	'仕': PieceShi, '士': PieceShi,
+	'相': PieceXiang, '象': PieceXiang,
+	'马': PieceMa, '馬': PieceMa, '傌': PieceMa,
+	'车': PieceJu, '車': PieceJu, '俥': PieceJu,
+	'炮': PiecePao, '砲': PiecePao, '包': PiecePao,
+	'兵': PieceBing, '卒': PieceBing}
+
+//NotationStruct 纵线记谱的走法描述，中文记谱和WXF记谱共用
+type NotationStruct struct {
+	pt      int  //棋子类型
+	sd      int  //走子方
+	nFile   int  //起点纵线，从本方右侧数起为1~9
+	nCount  int  //同一纵线上同类棋子的个数
+	nIndex  int  //在同一纵线上从前往后排第几个，0表示最前
+	bTandem bool //兵(卒)是否有两条以上纵线各有多个，此时用纵线代替棋子名称
+	nDir    int  //1=进，-1=退，0=平
+	nNum    int  //进退的步数或者终点纵线
+}
+
+//fileNum 获得本方视角的纵线编号，从本方右侧数起为1~9
+func fileNum(x, sd int) int {
+	if sd == 0 {
+		return Right - x + 1
+	}
+	return x - Left + 1
+}
+
+//isFront 在同一纵线上sqA是否比sqB靠前
+func isFront(sqA, sqB, sd int) bool {
+	if sd == 0 {
+		return getY(sqA) < getY(sqB)
+	}
+	return getY(sqA) > getY(sqB)
+}
+
+//describeMove 获得走法的纵线描述
+func (p *PositionStruct) describeMove(mv int) (NotationStruct, error) {
+	n := NotationStruct{}
+	sqSrc, sqDst := src(mv), dst(mv)
+	if mv < 0 || mv > 0xffff || !inBoard(sqSrc) || !inBoard(sqDst) {
+		return n, fmt.Errorf("notation: move %d out of board", mv)
+	}
+	pc := p.ucpcSquares[sqSrc]
+	if pc == 0 {
+		return n, fmt.Errorf("notation: no piece on %s", squareToICCS(sqSrc))
+	}
+	if pc >= 16 {
+		n.sd = 1
+	}
+	n.pt = pc - sideTag(n.sd)
+	n.nFile = fileNum(getX(sqSrc), n.sd)
+
+	//统计同一纵线上的同类棋子，以及有多个兵(卒)的纵线数
+	nTandemFiles := 0
+	for x := Left; x <= Right; x++ {
+		sqs := []int{}
+		for y := Top; y <= Bottom; y++ {
+			if p.ucpcSquares[squareXY(x, y)] == pc {
+				sqs = append(sqs, squareXY(x, y))
+			}
+		}
+		if len(sqs) > 1 {
+			nTandemFiles++
+		}
+		if x != getX(sqSrc) {
+			continue
+		}
+		sort.Slice(sqs, func(a, b int) bool {
+			return isFront(sqs[a], sqs[b], n.sd)
+		})
+		n.nCount = len(sqs)
+		for i, sq := range sqs {
+			if sq == sqSrc {
+				n.nIndex = i
+			}
+		}
+	}
+	n.bTandem = n.pt == PieceBing && n.nCount > 1 && nTandemFiles > 1
+
+	//进退的方向和数字
+	nDelta := getY(sqSrc) - getY(sqDst)
+	if n.sd == 1 {
+		nDelta = -nDelta
+	}
+	switch {
+	case nDelta > 0:
+		n.nDir = 1
+	case nDelta < 0:
+		n.nDir = -1
+		nDelta = -nDelta
+	}
+	if n.nDir == 0 || n.pt == PieceShi || n.pt == PieceXiang || n.pt == PieceMa {
+		n.nNum = fileNum(getX(sqDst), n.sd)
+	} else {
+		n.nNum = nDelta
+	}
+	return n, nil
+}
+
+//chineseDigit 获得中文记谱的数字，红方用中文数字，黑方用阿拉伯数字
+func chineseDigit(n, sd int) rune {
+	if sd == 0 {
+		return ccChineseDigit[n]
+	}
+	return rune('0' + n)
+}
+
+//chinesePrefix 获得同一纵线上多个同类棋子的前后标记
+func chinesePrefix(nCount, nIndex int) rune {
+	switch {
+	case nCount > 3:
+		return ccChineseDigit[nIndex+1]
+	case nIndex == 0:
+		return '前'
+	case nIndex == nCount-1:
+		return '后'
+	}
+	return '中'
+}
+
+//chinese 把纵线描述转换成中文记谱
+func (n NotationStruct) chinese() string {
+	str := make([]rune, 0, 4)
+	if n.nCount > 1 {
+		str = append(str, chinesePrefix(n.nCount, n.nIndex))
+		if n.bTandem {
+			str = append(str, chineseDigit(n.nFile, n.sd))
+		} else {
+			str = append(str, ccPieceName[n.sd][n.pt])
+		}
+	} else {
+		str = append(str, ccPieceName[n.sd][n.pt], chineseDigit(n.nFile, n.sd))
+	}
+	switch n.nDir {
+	case 1:
+		str = append(str, '进')
+	case -1:
+		str = append(str, '退')
+	default:
+		str = append(str, '平')
+	}
+	return string(append(str, chineseDigit(n.nNum, n.sd)))
+}
+
+//checkNotationMove 只有走子方的合法走法(走完之后本方不被将军)才能记谱
+func (p *PositionStruct) checkNotationMove(mv int) error {
+	if mv <= 0 || mv > 0xffff || !inBoard(src(mv)) || !inBoard(dst(mv)) {
+		return fmt.Errorf("notation: move %d out of board", mv)
+	}
+	if !p.legalMove(mv) {
+		return fmt.Errorf("notation: illegal move %s", moveToICCS(mv))
+	}
+	pcCaptured := p.movePiece(mv)
+	bChecked := p.checked()
+	p.undoMovePiece(mv, pcCaptured)
+	if bChecked {
+		return fmt.Errorf("notation: move %s leaves king in check", moveToICCS(mv))
+	}
+	return nil
+}
+
+//moveToChinese 把走法转换成中文记谱，例如"炮二平五"，走法必须是走子方的合法走法
+func (p *PositionStruct) moveToChinese(mv int) (string, error) {
+	if err := p.checkNotationMove(mv); err != nil {
+		return "", err
+	}
+	n, err := p.describeMove(mv)
+	if err != nil {
+		return "", err
+	}
+	return n.chinese(), nil
+}
+
+//digitValue 获得数字字符的值，兼容中文数字、阿拉伯数字和全角数字，无法识别返回0
+func digitValue(r rune) int {
+	switch {
+	case r >= '1' && r <= '9':
+		return int(r - '0')
+	case r >= '１' && r <= '９':
+		return int(r - '０')
+	}
+	for i := 1; i <= 9; i++ {
+		if ccChineseDigit[i] == r {
+			return i
+		}
+	}
+	return 0
+}
+
+//normalizeChinese 把中文记谱规范成走子方的写法，以便和生成的记谱比较
+func normalizeChinese(str string, sd int) (string, error) {
+	str = strings.Join(strings.Fields(str), "")
+	rs := []rune(str)
+	if len(rs) != 4 {
+		return "", fmt.Errorf("chinese: invalid move %q", str)
+	}
+
+	//第一个字是棋子、前后标记或者序号
+	if pt, ok := ccPieceAlias[rs[0]]; ok {
+		rs[0] = ccPieceName[sd][pt]
+	} else if rs[0] == '後' {
+		rs[0] = '后'
+	} else if n := digitValue(rs[0]); n > 0 {
+		rs[0] = ccChineseDigit[n]
+	} else if rs[0] != '前' && rs[0] != '中' && rs[0] != '后' {
+		return "", fmt.Errorf("chinese: invalid move %q", str)
+	}
+
+	//第二个字是纵线或者棋子
+	if pt, ok := ccPieceAlias[rs[1]]; ok {
+		rs[1] = ccPieceName[sd][pt]
+	} else if n := digitValue(rs[1]); n > 0 {
+		rs[1] = chineseDigit(n, sd)
+	} else {
+		return "", fmt.Errorf("chinese: invalid move %q", str)
+	}
+
+	//第三个字是方向
+	switch rs[2] {
+	case '进', '進':
+		rs[2] = '进'
+	case '退', '平':
+	default:
+		return "", fmt.Errorf("chinese: invalid move %q", str)
+	}
+
+	//第四个字是数字
+	if n := digitValue(rs[3]); n > 0 {
+		rs[3] = chineseDigit(n, sd)
+	} else {
+		return "", fmt.Errorf("chinese: invalid move %q", str)
+	}
+	return string(rs), nil
+}
+
+//chineseToMove 把中文记谱转换成走法，只接受合法的走法
+func (p *PositionStruct) chineseToMove(str string) (int, error) {
+	strMove, err := normalizeChinese(str, p.sdPlayer)
+	if err != nil {
+		return 0, err
+	}
+	mvs := make([]int, MaxGenMoves)
+	nLegalMoves := p.generateLegalMoves(mvs)
+	for i := 0; i < nLegalMoves; i++ {
+		if n, err := p.describeMove(mvs[i]); err == nil && n.chinese() == strMove {
+			return mvs[i], nil
+		}
+	}
+	return 0, fmt.Errorf("chinese: no legal move %q", str)
+}
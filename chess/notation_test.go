@@ -0,0 +1,157 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 纵线记谱(中文记谱)测试
+ */
+
+package chess
+
+import (
+	"testing"
+)
+
+//notationCase 记谱测试用例，fen为空表示初始局面，前面的走法先走好
+type notationCase struct {
+	fen    string
+	before []string
+	iccs   string
+	want   string
+}
+
+//notationPosition 创建用例的局面
+func notationPosition(t *testing.T, fen string, before []string) *Position {
+	t.Helper()
+	p := NewPosition()
+	if fen != "" {
+		var err error
+		if p, err = NewPositionFromFEN(fen); err != nil {
+			t.Fatalf("%q: %v", fen, err)
+		}
+	}
+	playMoves(t, p, before...)
+	return p
+}
+
+//notationFENs 各种前后、序号和多兵的局面
+const (
+	//九路有两个红车
+	fenTwoRooks = "3k5/9/9/9/9/9/R8/9/9/R3K4 w - - 0 1"
+	//五路有三个红兵
+	fenThreePawns = "3k5/9/4P4/4P4/4P4/9/9/9/9/5K3 w - - 0 1"
+	//五路有四个红兵
+	fenFourPawns = "3k5/4P4/4P4/4P4/4P4/9/9/9/9/5K3 w - - 0 1"
+	//七路和三路各有两个红兵
+	fenTandemPawns = "3k5/9/9/2P3P2/2P3P2/9/9/9/9/5K3 w - - 0 1"
+	//3路和7路各有两个黑卒，4路有两个黑车
+	fenBlackPieces = "4k4/9/9/9/9/2p3p2/2pr2p2/9/3r5/5K3 b - - 0 1"
+)
+
+//chineseCases 中文记谱用例
+var chineseCases = []notationCase{
+	{"", nil, "h2e2", "炮二平五"},
+	{"", nil, "b0c2", "马八进七"},
+	{"", nil, "a0a1", "车九进一"},
+	{"", nil, "c0e2", "相七进五"},
+	{"", nil, "f0e1", "仕四进五"},
+	{"", nil, "e3e4", "兵五进一"},
+	{"", []string{"h2e2"}, "h7e7", "炮8平5"},
+	{"", []string{"h2e2"}, "h9g7", "马8进7"},
+	{"", []string{"h2e2"}, "c6c5", "卒3进1"},
+	{"", []string{"h2e2"}, "e9e8", "将5进1"},
+	{"", []string{"h2e2"}, "h7h8", "炮8退1"},
+	{fenTwoRooks, nil, "a3a4", "前车进一"},
+	{fenTwoRooks, nil, "a0a1", "后车进一"},
+	{fenTwoRooks, nil, "a3b3", "前车平八"},
+	{fenTwoRooks, nil, "a0b0", "后车平八"},
+	{fenThreePawns, nil, "e7e8", "前兵进一"},
+	{fenThreePawns, nil, "e6d6", "中兵平六"},
+	{fenThreePawns, nil, "e5f5", "后兵平四"},
+	{fenFourPawns, nil, "e8d8", "一兵平六"},
+	{fenFourPawns, nil, "e7d7", "二兵平六"},
+	{fenFourPawns, nil, "e6f6", "三兵平四"},
+	{fenFourPawns, nil, "e5d5", "四兵平六"},
+	{fenTandemPawns, nil, "c6c7", "前七进一"},
+	{fenTandemPawns, nil, "g5h5", "后三平二"},
+	{fenBlackPieces, nil, "c3c2", "前3进1"},
+	{fenBlackPieces, nil, "g4h4", "后7平8"},
+	{fenBlackPieces, nil, "d1d2", "前车退1"},
+	{fenBlackPieces, nil, "d3e3", "后车平5"},
+}
+
+//TestChinese 生成中文记谱，再解析回原来的走法
+func TestChinese(t *testing.T) {
+	for _, c := range chineseCases {
+		p := notationPosition(t, c.fen, c.before)
+		mv, _ := ParseMove(c.iccs)
+		str, err := p.Chinese(mv)
+		if err != nil || str != c.want {
+			t.Errorf("%q %s: got %q, %v, want %q", c.fen, c.iccs, str, err, c.want)
+			continue
+		}
+		if mvParsed, err := p.ParseChinese(str); err != nil || mvParsed != mv {
+			t.Errorf("%q %s: ParseChinese(%q) = %v, %v", c.fen, c.iccs, str, mvParsed, err)
+		}
+	}
+}
+
+//TestChineseRoundTrip 每个局面的每个合法走法都能转换成中文记谱再解析回来
+func TestChineseRoundTrip(t *testing.T) {
+	for _, fen := range []string{"", fenTwoRooks, fenThreePawns, fenFourPawns, fenTandemPawns, fenBlackPieces} {
+		p := notationPosition(t, fen, nil)
+		for _, mv := range p.LegalMoves() {
+			str, err := p.Chinese(mv)
+			if err != nil {
+				t.Errorf("%q %v: %v", fen, mv, err)
+				continue
+			}
+			if mvParsed, err := p.ParseChinese(str); err != nil || mvParsed != mv {
+				t.Errorf("%q %v: ParseChinese(%q) = %v, %v", fen, mv, str, mvParsed, err)
+			}
+		}
+	}
+}
+
+//TestParseChineseVariants 解析异体字、繁体字、全角数字和另一方的数字写法
+func TestParseChineseVariants(t *testing.T) {
+	p := NewPosition()
+	for _, str := range []string{"炮二平五", "炮2平5", "砲二平五", "炮２平５", " 炮 二 平 五 "} {
+		if mv, err := p.ParseChinese(str); err != nil || mv.String() != "h2e2" {
+			t.Errorf("%q: got %v, %v", str, mv, err)
+		}
+	}
+	playMoves(t, p, "h2e2")
+	for _, str := range []string{"馬8進7", "马八进七"} {
+		if mv, err := p.ParseChinese(str); err != nil || mv.String() != "h9g7" {
+			t.Errorf("%q: got %v, %v", str, mv, err)
+		}
+	}
+	for _, str := range []string{"", "炮二平", "炮二走五", "车二进一", "炮二进十", "前炮平五"} {
+		if mv, err := p.ParseChinese(str); err == nil {
+			t.Errorf("%q: got %v, want error", str, mv)
+		}
+	}
+}
+
+//TestChineseIllegal 不是走子方合法走法的走法不能记谱
+func TestChineseIllegal(t *testing.T) {
+	cases := []struct {
+		fen  string
+		iccs string
+	}{
+		{"", "h7e7"}, //轮到红方走，不能给黑方的走法记谱
+		{"", "a0a5"}, //车不能越子
+		{"", "e1e2"}, //没有棋子
+		{"4k4/4r4/9/9/9/9/9/9/4R4/4K4 w - - 0 1", "e1a1"}, //车被牵制，走开就送将
+	}
+	for _, c := range cases {
+		p := notationPosition(t, c.fen, nil)
+		mv, _ := ParseMove(c.iccs)
+		if str, err := p.Chinese(mv); err == nil {
+			t.Errorf("%q %s: got %q, want error", c.fen, c.iccs, str)
+		}
+	}
+	if str, err := NewPosition().Chinese(0); err == nil {
+		t.Errorf("no move: got %q, want error", str)
+	}
+}
@@ -0,0 +1,59 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * Perft走法生成校验
+ */
+
+package chess
+
+//perft 统计指定深度的叶子节点数，只统计走法生成、legalMove和makeMove都认可的走法
+func (p *PositionStruct) perft(nDepth int) int {
+	if nDepth <= 0 {
+		return 1
+	}
+	nNodes := 0
+	mvs := make([]int, MaxGenMoves)
+	nGenMoves := p.generateMoves(mvs, false)
+	for i := 0; i < nGenMoves; i++ {
+		if !p.legalMove(mvs[i]) || !p.makeMove(mvs[i]) {
+			continue
+		}
+		if nDepth == 1 {
+			nNodes++
+		} else {
+			nNodes += p.perft(nDepth - 1)
+		}
+		p.undoMakeMove()
+	}
+	return nNodes
+}
+
+//perftDivide 分别统计每个根节点走法的叶子节点数
+func (p *PositionStruct) perftDivide(nDepth int) map[int]int {
+	result := map[int]int{}
+	mvs := make([]int, MaxGenMoves)
+	nGenMoves := p.generateMoves(mvs, false)
+	for i := 0; i < nGenMoves; i++ {
+		if !p.legalMove(mvs[i]) || !p.makeMove(mvs[i]) {
+			continue
+		}
+		result[mvs[i]] = p.perft(nDepth - 1)
+		p.undoMakeMove()
+	}
+	return result
+}
+
+//Perft 统计指定深度的叶子节点数
+func (p *Position) Perft(nDepth int) int {
+	return p.pos.perft(nDepth)
+}
+
+//Divide 分别统计每个合法走法之后指定深度的叶子节点数
+func (p *Position) Divide(nDepth int) map[Move]int {
+	result := map[Move]int{}
+	for mv, nNodes := range p.pos.perftDivide(nDepth) {
+		result[Move(mv)] = nNodes
+	}
+	return result
+}
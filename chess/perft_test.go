@@ -0,0 +1,74 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * Perft走法生成测试
+ */
+
+package chess
+
+import (
+	"testing"
+)
+
+//perftCase Perft校验局面
+type perftCase struct {
+	FEN   string //局面
+	Nodes []int  //深度1、2、3...的叶子节点数
+}
+
+//perftCases 已公布的Perft结果，用来校验走法生成
+var perftCases = []perftCase{
+	{StartupFEN, []int{44, 1920, 79666, 3290240, 133312995}},
+	{"r1ba1a3/4kn3/2n1b4/pNp1p1p1p/4c4/6P2/P1P2R2P/1CcC5/9/2BAKAB2 w - - 0 1", []int{38, 1128, 43929, 1339047}},
+	{"1cbak4/9/n2a5/2p1p3p/5cp2/2n2N3/6PCP/3AB4/2C6/3A1K1N1 w - - 0 1", []int{7, 281, 8620, 326201}},
+	{"5a3/3k5/3aR4/9/5r3/5n3/9/3A1A3/5K3/2BC2B2 w - - 0 1", []int{25, 424, 9850, 202884}},
+	{"CRN1k1b2/3ca4/4ba3/9/2nr5/9/9/4B4/4A4/4KA3 w - - 0 1", []int{28, 516, 14808, 395483}},
+	{"R1N1k1b2/9/3aba3/9/2nr5/2B6/9/4B4/4A4/4KA3 w - - 0 1", []int{21, 364, 7626, 162837}},
+	{"C1nNk4/9/9/9/9/9/n1pp5/B3C4/9/3A1K3 w - - 0 1", []int{28, 222, 6241, 64971}},
+	{"4ka3/4a4/9/9/4N4/p8/9/4C3c/7n1/2BK5 w - - 0 1", []int{23, 345, 8124, 149272}},
+	{"2b1ka3/9/b3N4/4n4/9/9/9/4C4/2p6/2BK5 w - - 0 1", []int{21, 195, 3883, 48060}},
+}
+
+//perftShortDepth -short时只校验不超过这个深度的结果
+const perftShortDepth = 3
+
+//TestPerft 用已公布的Perft结果校验走法生成，-short时跳过较深的结果
+func TestPerft(t *testing.T) {
+	for _, c := range perftCases {
+		p, err := NewPositionFromFEN(c.FEN)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, nWant := range c.Nodes {
+			nDepth := i + 1
+			if nDepth > perftShortDepth && testing.Short() {
+				break
+			}
+			if nNodes := p.Perft(nDepth); nNodes != nWant {
+				t.Errorf("%q depth %d: got %d, want %d", c.FEN, nDepth, nNodes, nWant)
+			}
+		}
+	}
+}
+
+//TestDivide 每个根节点走法的叶子节点数加起来等于Perft的结果
+func TestDivide(t *testing.T) {
+	for _, c := range perftCases {
+		p, err := NewPositionFromFEN(c.FEN)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := p.Divide(2)
+		nNodes := 0
+		for mv, n := range result {
+			if !p.pos.legalMove(int(mv)) {
+				t.Errorf("%q: divide returned illegal move %v", c.FEN, mv)
+			}
+			nNodes += n
+		}
+		if len(result) != c.Nodes[0] || nNodes != c.Nodes[1] {
+			t.Errorf("%q: divide got %d moves and %d nodes, want %d and %d", c.FEN, len(result), nNodes, c.Nodes[0], c.Nodes[1])
+		}
+	}
+}
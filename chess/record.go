@@ -0,0 +1,149 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 棋谱
+ */
+
+package chess
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//棋谱格式和PGN类似，先是标签，再是ICCS格式的走法，例如：
+//[Game "Chinese Chess"]
+//[Format "ICCS"]
+//[Handicap "让单马"]
+//[FEN "rnbakabnr/9/1c5c1/p1p1p1p1p/9/9/P1P1P1P1P/1C5C1/9/R1BAKABNR w - - 0 1"]
+//[Result "1-0"]
+//[Termination "checkmate"]
+//
+//1. h2e2 h9g7 2. h0g2 i9h9 1-0
+
+//cszRecordResult 棋谱中的对局结果，顺序同OutcomeNone...OutcomeDraw
+var cszRecordResult = [...]string{"*", "1-0", "0-1", "1/2-1/2"}
+
+//Record 生成棋谱，包括让子、起始局面、走法和对局结果
+func (p *Position) Record() string {
+	var sb strings.Builder
+	sb.WriteString("[Game \"Chinese Chess\"]\n")
+	sb.WriteString("[Format \"ICCS\"]\n")
+	if p.handicap != HandicapNone {
+		fmt.Fprintf(&sb, "[Handicap \"%s\"]\n", p.handicap)
+	}
+	fmt.Fprintf(&sb, "[FEN \"%s\"]\n", p.szStartFEN)
+	result := p.Result()
+	fmt.Fprintf(&sb, "[Result \"%s\"]\n", cszRecordResult[result.Outcome])
+	if result.IsOver() {
+		fmt.Fprintf(&sb, "[Termination \"%s\"]\n", result.Reason)
+	}
+	sb.WriteString("\n")
+
+	//回合数从FEN中的回合数开始，起始局面可能轮到黑方走
+	nMove, sd := p.pos.nStartRound, Red
+	if fields := strings.Fields(p.szStartFEN); len(fields) > 1 && fields[1] == "b" {
+		sd = Black
+	}
+	for i, mv := range p.mvs {
+		if sd == Red {
+			fmt.Fprintf(&sb, "%d. ", nMove)
+		} else if i == 0 {
+			fmt.Fprintf(&sb, "%d. ... ", nMove)
+		}
+		sb.WriteString(mv.String())
+		sb.WriteString(" ")
+		if sd == Black {
+			nMove++
+		}
+		sd = 1 - sd
+	}
+	sb.WriteString(cszRecordResult[result.Outcome])
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+//ParseRecord 读取棋谱，重走所有走法，认输、超时和议和的结果也会恢复
+func ParseRecord(str string) (*Position, error) {
+	tags := map[string]string{}
+	lines := strings.Split(str, "\n")
+	i := 0
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "[") {
+			break
+		}
+		if !strings.HasSuffix(line, "]") {
+			return nil, fmt.Errorf("record: bad tag %q", line)
+		}
+		fields := strings.SplitN(line[1:len(line)-1], " ", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("record: bad tag %q", line)
+		}
+		value, err := strconv.Unquote(strings.TrimSpace(fields[1]))
+		if err != nil {
+			return nil, fmt.Errorf("record: bad tag %q", line)
+		}
+		tags[fields[0]] = value
+	}
+
+	//有FEN时按FEN摆棋盘，否则按让子摆棋盘
+	h := HandicapNone
+	if name, ok := tags["Handicap"]; ok {
+		var err error
+		if h, err = ParseHandicap(name); err != nil {
+			return nil, err
+		}
+	}
+	var p *Position
+	if fen, ok := tags["FEN"]; ok {
+		var err error
+		if p, err = NewPositionFromFEN(fen); err != nil {
+			return nil, err
+		}
+		p.handicap = h
+	} else {
+		p = NewPosition()
+		p.SetHandicap(h)
+	}
+
+	for _, token := range strings.Fields(strings.Join(lines[i:], " ")) {
+		if strings.HasSuffix(token, ".") || token == "..." {
+			continue
+		}
+		if token == "*" || token == "1-0" || token == "0-1" || token == "1/2-1/2" {
+			break
+		}
+		mv, err := ParseMove(token)
+		if err != nil {
+			return nil, fmt.Errorf("record: %v", err)
+		}
+		if err := p.Play(mv); err != nil {
+			return nil, fmt.Errorf("record: %s: %v", token, err)
+		}
+	}
+
+	//按局面裁定不出来的结果
+	if !p.Result().IsOver() {
+		switch tags["Termination"] {
+		case ReasonResignation.String(), ReasonTimeout.String():
+			sdLoser := Black
+			if tags["Result"] == cszRecordResult[OutcomeBlackWin] {
+				sdLoser = Red
+			}
+			if tags["Termination"] == ReasonResignation.String() {
+				p.Resign(sdLoser)
+			} else {
+				p.Timeout(sdLoser)
+			}
+		case ReasonAgreement.String():
+			p.AgreeDraw()
+		}
+	}
+	return p, nil
+}
@@ -0,0 +1,109 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 棋谱测试
+ */
+
+package chess
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+//TestRecordRoundTrip 生成的棋谱读回来以后起始局面、让子、走法和对局结果都不变
+func TestRecordRoundTrip(t *testing.T) {
+	cases := []struct {
+		name     string
+		fen      string
+		handicap Handicap
+		moves    []string
+		end      func(p *Position) error
+	}{
+		{"startpos", "", HandicapNone, []string{"h2e2", "h9g7", "h0g2"}, nil},
+		{"handicap", "", HandicapTwoMa, []string{"h2e2", "h7e7"}, nil},
+		{"black to move", "4k4/9/9/9/9/9/9/9/4R4/3K5 b - - 4 12", HandicapNone, []string{"e9f9", "e1d1", "f9e9"}, nil},
+		{"checkmate", "4k4/R8/9/9/9/9/9/9/9/3K4R w - - 0 1", HandicapNone, []string{"i0i9"}, nil},
+		{"red resigns", "", HandicapNone, []string{"h2e2"}, func(p *Position) error { return p.Resign(Red) }},
+		{"black resigns", "4k4/9/9/9/9/9/9/9/4R4/3K5 b - - 0 1", HandicapNone, nil, func(p *Position) error { return p.Resign(Black) }},
+		{"red timeout", "", HandicapJu, []string{"h2e2", "h7e7"}, func(p *Position) error { return p.Timeout(Red) }},
+		{"black timeout", "", HandicapNone, []string{"h2e2"}, func(p *Position) error { return p.Timeout(Black) }},
+		{"agreement", "", HandicapNone, []string{"h2e2", "h7e7"}, func(p *Position) error { return p.AgreeDraw() }},
+	}
+	for _, c := range cases {
+		p := NewPosition()
+		if c.fen != "" {
+			var err error
+			if p, err = NewPositionFromFEN(c.fen); err != nil {
+				t.Fatalf("%s: %v", c.name, err)
+			}
+		}
+		if c.handicap != HandicapNone {
+			p.SetHandicap(c.handicap)
+		}
+		playMoves(t, p, c.moves...)
+		if c.end != nil {
+			if err := c.end(p); err != nil {
+				t.Fatalf("%s: %v", c.name, err)
+			}
+		}
+
+		str := p.Record()
+		got, err := ParseRecord(str)
+		if err != nil {
+			t.Errorf("%s: %v\n%s", c.name, err, str)
+			continue
+		}
+		if got.FEN() != p.FEN() || got.Handicap() != p.Handicap() || !reflect.DeepEqual(got.Moves(), p.Moves()) {
+			t.Errorf("%s: got %q %v %v, want %q %v %v", c.name, got.FEN(), got.Handicap(), got.Moves(), p.FEN(), p.Handicap(), p.Moves())
+		}
+		if got.Result() != p.Result() {
+			t.Errorf("%s: result %v, want %v", c.name, got.Result(), p.Result())
+		}
+		if got.Record() != str {
+			t.Errorf("%s: record changed\n%s\nwant\n%s", c.name, got.Record(), str)
+		}
+	}
+}
+
+//TestParseRecordErrors 标签和走法不对的棋谱返回错误
+func TestParseRecordErrors(t *testing.T) {
+	for _, str := range []string{
+		"[Game \"Chinese Chess\"\n\n1. h2e2 *\n",
+		"[Game]\n\n1. h2e2 *\n",
+		"[Handicap \"让双车\"]\n\n1. h2e2 *\n",
+		"[FEN \"9/9/9 w - - 0 1\"]\n\n*\n",
+		"1. h2e2 h2e2 *\n",
+		"1. z2e2 *\n",
+	} {
+		if _, err := ParseRecord(str); err == nil {
+			t.Errorf("%q: no error", str)
+		}
+	}
+}
+
+//TestRecordMoveNumbers 棋谱的回合数接着起始局面FEN中的回合数
+func TestRecordMoveNumbers(t *testing.T) {
+	cases := []struct {
+		fen   string
+		moves []string
+		want  string
+	}{
+		{"4k4/9/9/9/9/9/9/9/R8/3K5 w - - 0 1", []string{"a1a2", "e9f9"}, "1. a1a2 e9f9 *"},
+		{"4k4/9/9/9/9/9/9/9/R8/3K5 w - - 0 7", []string{"a1a2", "e9f9", "a2b2"}, "7. a1a2 e9f9 8. a2b2 *"},
+		{"4k4/9/9/9/9/9/9/9/4R4/3K5 b - - 4 12", []string{"e9f9", "e1d1", "f9e9"}, "12. ... e9f9 13. e1d1 f9e9 *"},
+	}
+	for _, c := range cases {
+		p, err := NewPositionFromFEN(c.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		playMoves(t, p, c.moves...)
+		str := p.Record()
+		if got := str[strings.LastIndex(str, "\n\n")+2 : len(str)-1]; got != c.want {
+			t.Errorf("%s: got %q, want %q", c.fen, got, c.want)
+		}
+	}
+}
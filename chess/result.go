@@ -0,0 +1,197 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 对局结果
+ */
+
+package chess
+
+import (
+	"errors"
+)
+
+//ErrGameOver 对局已经结束
+var ErrGameOver = errors.New("chess: game is over")
+
+//Outcome 胜负
+type Outcome int
+
+const (
+	//OutcomeNone 未分胜负
+	OutcomeNone Outcome = iota
+	//OutcomeRedWin 红方胜
+	OutcomeRedWin
+	//OutcomeBlackWin 黑方胜
+	OutcomeBlackWin
+	//OutcomeDraw 和棋
+	OutcomeDraw
+)
+
+//cszOutcome 胜负的说明
+var cszOutcome = [...]string{"none", "red wins", "black wins", "draw"}
+
+//String 胜负的说明
+func (o Outcome) String() string {
+	if o < 0 || int(o) >= len(cszOutcome) {
+		return "unknown"
+	}
+	return cszOutcome[o]
+}
+
+//Reason 对局结束的原因
+type Reason int
+
+const (
+	//ReasonNone 对局没有结束
+	ReasonNone Reason = iota
+	//ReasonCheckmate 将死
+	ReasonCheckmate
+	//ReasonStalemate 困毙，走子方没有被将军但是无棋可走
+	ReasonStalemate
+	//ReasonPerpetualCheck 长将判负
+	ReasonPerpetualCheck
+	//ReasonPerpetualChase 长捉判负
+	ReasonPerpetualChase
+	//ReasonRepetition 重复局面判和
+	ReasonRepetition
+	//ReasonMoveLimit 超过回合数限制判和
+	ReasonMoveLimit
+	//ReasonInsufficientMaterial 双方都没有进攻棋子判和
+	ReasonInsufficientMaterial
+	//ReasonResignation 认输
+	ReasonResignation
+	//ReasonTimeout 超时
+	ReasonTimeout
+	//ReasonAgreement 双方同意和棋
+	ReasonAgreement
+	//ReasonNoPieces 棋子被吃光判负
+	ReasonNoPieces
+)
+
+//cszReason 结束原因的说明
+var cszReason = [...]string{"none", "checkmate", "stalemate", "perpetual check", "perpetual chase",
+	"repetition", "move limit", "insufficient material", "resignation", "timeout", "agreement", "no pieces"}
+
+//String 结束原因的说明
+func (r Reason) String() string {
+	if r < 0 || int(r) >= len(cszReason) {
+		return "unknown"
+	}
+	return cszReason[r]
+}
+
+//Result 对局结果，包括胜负和结束原因
+type Result struct {
+	Outcome Outcome //胜负
+	Reason  Reason  //结束原因
+}
+
+//IsOver 对局是否已经结束
+func (r Result) IsOver() bool {
+	return r.Outcome != OutcomeNone
+}
+
+//Winner 获胜的一方，没有分出胜负时返回-1
+func (r Result) Winner() int {
+	switch r.Outcome {
+	case OutcomeRedWin:
+		return Red
+	case OutcomeBlackWin:
+		return Black
+	}
+	return -1
+}
+
+//String 对局结果的说明，例如"red wins by checkmate"
+func (r Result) String() string {
+	switch r.Outcome {
+	case OutcomeNone:
+		return r.Outcome.String()
+	case OutcomeDraw:
+		return "draw by " + r.Reason.String()
+	}
+	return r.Outcome.String() + " by " + r.Reason.String()
+}
+
+//winResult 某一方获胜的结果
+func winResult(sd int, r Reason) Result {
+	if sd == Red {
+		return Result{OutcomeRedWin, r}
+	}
+	return Result{OutcomeBlackWin, r}
+}
+
+//drawResult 和棋的结果
+func drawResult(r Reason) Result {
+	return Result{OutcomeDraw, r}
+}
+
+//adjudicate 根据局面和规则裁定对局结果
+func (p *PositionStruct) adjudicate(r Ruleset) Result {
+	//走子方无棋可走，被将军是将死，否则是困毙，都判负
+	if p.isMate() {
+		if p.checked() {
+			return winResult(1-p.sdPlayer, ReasonCheckmate)
+		}
+		return winResult(1-p.sdPlayer, ReasonStalemate)
+	}
+
+	//重复局面，分值是对走子方来说的
+	nRepStatus := p.repStatus(3, true)
+	if nRepStatus > 0 {
+		vlRep := p.repValue(nRepStatus)
+		if vlRep > WinValue {
+			//对方长将或长捉
+			if nRepStatus&4 != 0 {
+				return winResult(p.sdPlayer, ReasonPerpetualCheck)
+			}
+			return winResult(p.sdPlayer, ReasonPerpetualChase)
+		} else if vlRep < -WinValue {
+			//本方长将或长捉
+			if nRepStatus&2 != 0 {
+				return winResult(1-p.sdPlayer, ReasonPerpetualCheck)
+			}
+			return winResult(1-p.sdPlayer, ReasonPerpetualChase)
+		}
+		return drawResult(ReasonRepetition)
+	}
+
+	//按规则判和
+	if rs := p.ruleDraw(r, p.noCaptureMoves(), p.gamePlies()); rs != ReasonNone {
+		return drawResult(rs)
+	}
+	return Result{}
+}
+
+//Result 对局结果，认输、超时和议和优先，否则根据局面裁定
+func (p *Position) Result() Result {
+	if p.result.IsOver() {
+		return p.result
+	}
+	return p.pos.adjudicate(p.rules)
+}
+
+//declare 宣布对局结果，对局已经结束时返回ErrGameOver
+func (p *Position) declare(r Result) error {
+	if p.Result().IsOver() {
+		return ErrGameOver
+	}
+	p.result = r
+	return nil
+}
+
+//Resign sd一方认输
+func (p *Position) Resign(sd int) error {
+	return p.declare(winResult(1-sd, ReasonResignation))
+}
+
+//Timeout sd一方超时判负
+func (p *Position) Timeout(sd int) error {
+	return p.declare(winResult(1-sd, ReasonTimeout))
+}
+
+//AgreeDraw 双方同意和棋
+func (p *Position) AgreeDraw() error {
+	return p.declare(drawResult(ReasonAgreement))
+}
@@ -0,0 +1,53 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 对局结果测试
+ */
+
+package chess
+
+import (
+	"testing"
+)
+
+//TestPlayAfterResult 按局面裁定出结果之后不能再走棋
+func TestPlayAfterResult(t *testing.T) {
+	cases := []struct {
+		name  string
+		fen   string
+		cycle []string
+		want  Result
+		mv    string
+	}{
+		{"checkmate", "3k5/R8/9/9/9/9/9/9/9/3RK4 b - - 0 1", nil,
+			winResult(Red, ReasonCheckmate), "d9e9"},
+		{"stalemate", "3k5/R8/9/9/9/9/9/9/9/4K4 b - - 0 1", nil,
+			winResult(Red, ReasonStalemate), "d9d8"},
+		{"perpetual check", "3k5/R8/9/9/9/9/9/9/9/4K4 w - - 0 1", []string{"a8a9", "d9d8", "a9a8", "d8d9"},
+			winResult(Black, ReasonPerpetualCheck), "a8a7"},
+		{"perpetual chase", "3k5/9/n8/9/9/9/9/9/2R6/4K4 w - - 0 1", []string{"c1a1", "a7c8", "a1c1", "c8a7"},
+			winResult(Black, ReasonPerpetualChase), "c1c2"},
+		{"repetition", "3k5/9/n7n/9/9/9/9/9/R8/4K4 w - - 0 1", []string{"a1i1", "d9d8", "i1a1", "d8d9"},
+			drawResult(ReasonRepetition), "a1a2"},
+		{"move limit", "3k5/9/n8/9/9/9/9/9/2R6/4K4 w - - 119 80", []string{"c1c2"},
+			drawResult(ReasonMoveLimit), "a7c8"},
+		{"insufficient material", "3k5/9/9/9/9/9/9/9/4A4/4K4 w - - 0 1", nil,
+			drawResult(ReasonInsufficientMaterial), "e1d2"},
+	}
+	for _, c := range cases {
+		nTimes := 3
+		if len(c.cycle) == 1 {
+			nTimes = 1
+		}
+		p := playCycle(t, c.fen, c.cycle, nTimes)
+		if r := p.Result(); r != c.want {
+			t.Errorf("%s: result %v, want %v", c.name, r, c.want)
+			continue
+		}
+		mv, _ := ParseMove(c.mv)
+		if err := p.Play(mv); err != ErrGameOver {
+			t.Errorf("%s: play %s got %v, want %v", c.name, c.mv, err, ErrGameOver)
+		}
+	}
+}
@@ -9,6 +9,7 @@ package chess
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -55,120 +56,106 @@ func (r *RC4Struct) nextLong() uint32 {
 	return uc0 + (uc1 << 8) + (uc2 << 16) + (uc3 << 24)
 }
 
-//ZobristStruct Zobrist结构
+//ZobristStruct Zobrist结构，64位校验码的高32位是原来的dwLock0，低32位是原来的dwLock1
 type ZobristStruct struct {
-	dwKey   uint32
-	dwLock0 uint32
-	dwLock1 uint32
+	qwKey uint64
 }
 
 //initZero 用零填充Zobrist
 func (z *ZobristStruct) initZero() {
-	z.dwKey, z.dwLock0, z.dwLock1 = 0, 0, 0
+	z.qwKey = 0
 }
 
 //initRC4 用密码流填充Zobrist
 func (z *ZobristStruct) initRC4(rc4 *RC4Struct) {
-	z.dwKey = rc4.nextLong()
-	z.dwLock0 = rc4.nextLong()
-	z.dwLock1 = rc4.nextLong()
+	//原来的dwKey不再使用，但要跳过，保证dwLock1和"book.dat"一致
+	rc4.nextLong()
+	dwLock0 := rc4.nextLong()
+	dwLock1 := rc4.nextLong()
+	z.qwKey = uint64(dwLock0)<<32 | uint64(dwLock1)
+}
+
+//bookLock 开局库用的32位校验码，即原来的dwLock1
+func (z *ZobristStruct) bookLock() uint32 {
+	return uint32(z.qwKey)
+}
+
+//hashIndex 置换表的下标
+func (z *ZobristStruct) hashIndex() uint64 {
+	return (z.qwKey >> 32) & (HashSize - 1)
 }
 
 //xor1 执行XOR操作
 func (z *ZobristStruct) xor1(zobr *ZobristStruct) {
-	z.dwKey ^= zobr.dwKey
-	z.dwLock0 ^= zobr.dwLock0
-	z.dwLock1 ^= zobr.dwLock1
+	z.qwKey ^= zobr.qwKey
 }
 
 //xor2 执行XOR操作
 func (z *ZobristStruct) xor2(zobr1, zobr2 *ZobristStruct) {
-	z.dwKey ^= zobr1.dwKey ^ zobr2.dwKey
-	z.dwLock0 ^= zobr1.dwLock0 ^ zobr2.dwLock0
-	z.dwLock1 ^= zobr1.dwLock1 ^ zobr2.dwLock1
+	z.qwKey ^= zobr1.qwKey ^ zobr2.qwKey
 }
 
 //Zobrist Zobrist表
 type Zobrist struct {
-	Player *ZobristStruct          //走子方
-	Table  [14][256]*ZobristStruct //所有棋子
+	Player ZobristStruct          //走子方
+	Table  [14][256]ZobristStruct //所有棋子
 }
 
-//initZobrist 初始化Zobrist表
-func (z *Zobrist) initZobrist() {
+//zobrist 所有局面共用的Zobrist表，初始化之后只读
+var zobrist = newZobrist()
+
+//newZobrist 用RC4密码流生成Zobrist表
+func newZobrist() *Zobrist {
+	z := &Zobrist{}
 	rc4 := &RC4Struct{}
 	rc4.initZero()
 	z.Player.initRC4(rc4)
 	for i := 0; i < 14; i++ {
 		for j := 0; j < 256; j++ {
-			z.Table[i][j] = &ZobristStruct{}
 			z.Table[i][j].initRC4(rc4)
 		}
 	}
+	return z
 }
 
 //MoveStruct 历史走法信息
 type MoveStruct struct {
-	ucpcCaptured int  //是否吃子
-	ucbCheck     bool //是否将军
-	wmv          int  //走法
-	dwKey        uint32
-}
-
-//set 设置
-func (m *MoveStruct) set(mv, pcCaptured int, bCheck bool, dwKey uint32) {
-	m.wmv = mv
-	m.ucpcCaptured = pcCaptured
-	m.ucbCheck = bCheck
-	m.dwKey = dwKey
+	ucpcCaptured int    //是否吃子
+	ucbCheck     bool   //是否将军
+	wmv          int    //走法
+	qwKey        uint64 //走棋之前局面的zobrist校验码
 }
 
 //PositionStruct 局面结构
 type PositionStruct struct {
-	sdPlayer    int                   //轮到谁走，0=红方，1=黑方
-	vlRed       int                   //红方的子力价值
-	vlBlack     int                   //黑方的子力价值
-	nDistance   int                   //距离根节点的步数
-	nMoveNum    int                   //历史走法数
-	ucpcSquares [256]int              //棋盘上的棋子
-	mvsList     [MaxMoves]*MoveStruct //历史走法信息列表
-	zobr        *ZobristStruct        //走子方zobrist校验码
-	zobrist     *Zobrist              //所有棋子zobrist校验码
-	search      *Search
-}
-
-//NewPositionStruct 初始化棋局
-func NewPositionStruct() *PositionStruct {
-	p := &PositionStruct{
-		zobr: &ZobristStruct{
-			dwKey:   0,
-			dwLock0: 0,
-			dwLock1: 0,
-		},
-		zobrist: &Zobrist{
-			Player: &ZobristStruct{
-				dwKey:   0,
-				dwLock0: 0,
-				dwLock1: 0,
-			},
-		},
-		search: &Search{},
-	}
-	if p == nil {
-		return nil
-	}
-
-	for i := 0; i < MaxMoves; i++ {
-		tmpMoveStruct := &MoveStruct{}
-		p.mvsList[i] = tmpMoveStruct
-	}
+	sdPlayer    int                //轮到谁走，0=红方，1=黑方
+	vlRed       int                //红方的子力价值
+	vlBlack     int                //黑方的子力价值
+	nDistance   int                //距离根节点的步数
+	nMoveNum    int                //历史走法数
+	nStartClock int                //起始局面之前没有吃子的步数(FEN中的半回合数)
+	nStartRound int                //起始局面的回合数(FEN中的回合数)
+	ucpcSquares [256]int           //棋盘上的棋子
+	mvsList     []MoveStruct       //历史走法信息列表，第0项是起始局面，随对局增长
+	bbPieces    [2][7]bitboard     //每方每种棋子的位棋盘
+	bbSides     [2]bitboard        //每方所有棋子的位棋盘
+	wBitRanks   [BoardRanks]uint16 //每一行的占位
+	wBitFiles   [BoardFiles]uint16 //每一列的占位
+	zobr        ZobristStruct      //局面的zobrist校验码
+	search      *Search            //搜索用的表，不属于局面，由调用方设置
+}
 
-	for i := 0; i < HashSize; i++ {
-		p.search.hashTable[i] = &HashItem{}
-	}
+//NewPositionStruct 初始化棋局，搜索用的表要另外设置
+func NewPositionStruct() *PositionStruct {
+	return &PositionStruct{}
+}
 
-	p.zobrist.initZobrist()
-	return p
+//clone 复制局面，包括棋盘、历史走法和校验码，不包括搜索用的表
+func (p *PositionStruct) clone() *PositionStruct {
+	posClone := *p
+	posClone.mvsList = append([]MoveStruct(nil), p.mvsList[:p.nMoveNum]...)
+	posClone.search = nil
+	return &posClone
 }
 
 //loadBook 加载开局库
@@ -226,16 +213,32 @@ func (p *PositionStruct) loadBook() bool {
 //clearBoard 清空棋盘
 func (p *PositionStruct) clearBoard() {
 	p.sdPlayer, p.vlRed, p.vlBlack, p.nDistance = 0, 0, 0, 0
+	p.nStartClock, p.nStartRound = 0, 1
 	for i := 0; i < 256; i++ {
 		p.ucpcSquares[i] = 0
 	}
+	p.bbPieces = [2][7]bitboard{}
+	p.bbSides = [2]bitboard{}
+	p.wBitRanks = [BoardRanks]uint16{}
+	p.wBitFiles = [BoardFiles]uint16{}
 	p.zobr.initZero()
 }
 
+//pushMove 记录一步历史走法，历史走法信息列表不够长时自动增长
+func (p *PositionStruct) pushMove(mv, pcCaptured int, bCheck bool, qwKey uint64) {
+	p.mvsList = append(p.mvsList[:p.nMoveNum], MoveStruct{
+		ucpcCaptured: pcCaptured,
+		ucbCheck:     bCheck,
+		wmv:          mv,
+		qwKey:        qwKey,
+	})
+	p.nMoveNum++
+}
+
 //setIrrev 清空(初始化)历史走法信息
 func (p *PositionStruct) setIrrev() {
-	p.mvsList[0].set(0, 0, p.checked(), p.zobr.dwKey)
-	p.nMoveNum = 1
+	p.nMoveNum = 0
+	p.pushMove(0, 0, p.checked(), p.zobr.qwKey)
 }
 
 //startup 初始化棋盘
@@ -254,32 +257,34 @@ func (p *PositionStruct) startup() {
 //changeSide 交换走子方
 func (p *PositionStruct) changeSide() {
 	p.sdPlayer = 1 - p.sdPlayer
-	p.zobr.xor1(p.zobrist.Player)
+	p.zobr.xor1(&zobrist.Player)
 }
 
 //addPiece 在棋盘上放一枚棋子
 func (p *PositionStruct) addPiece(sq, pc int) {
 	p.ucpcSquares[sq] = pc
+	p.addBitPiece(sq, pc)
 	//红方加分，黑方(注意"cucvlPiecePos"取值要颠倒)减分
 	if pc < 16 {
 		p.vlRed += cucvlPiecePos[pc-8][sq]
-		p.zobr.xor1(p.zobrist.Table[pc-8][sq])
+		p.zobr.xor1(&zobrist.Table[pc-8][sq])
 	} else {
 		p.vlBlack += cucvlPiecePos[pc-16][squareFlip(sq)]
-		p.zobr.xor1(p.zobrist.Table[pc-9][sq])
+		p.zobr.xor1(&zobrist.Table[pc-9][sq])
 	}
 }
 
 //delPiece 从棋盘上拿走一枚棋子
 func (p *PositionStruct) delPiece(sq, pc int) {
 	p.ucpcSquares[sq] = 0
+	p.delBitPiece(sq, pc)
 	//红方减分，黑方(注意"cucvlPiecePos"取值要颠倒)加分
 	if pc < 16 {
 		p.vlRed -= cucvlPiecePos[pc-8][sq]
-		p.zobr.xor1(p.zobrist.Table[pc-8][sq])
+		p.zobr.xor1(&zobrist.Table[pc-8][sq])
 	} else {
 		p.vlBlack -= cucvlPiecePos[pc-16][squareFlip(sq)]
-		p.zobr.xor1(p.zobrist.Table[pc-9][sq])
+		p.zobr.xor1(&zobrist.Table[pc-9][sq])
 	}
 }
 
@@ -330,15 +335,14 @@ func (p *PositionStruct) undoMovePiece(mv, pcCaptured int) {
 
 //makeMove 走一步棋
 func (p *PositionStruct) makeMove(mv int) bool {
-	dwKey := p.zobr.dwKey
+	qwKey := p.zobr.qwKey
 	pcCaptured := p.movePiece(mv)
 	if p.checked() {
 		p.undoMovePiece(mv, pcCaptured)
 		return false
 	}
 	p.changeSide()
-	p.mvsList[p.nMoveNum].set(mv, pcCaptured, p.checked(), dwKey)
-	p.nMoveNum++
+	p.pushMove(mv, pcCaptured, p.checked(), qwKey)
 	p.nDistance++
 	return true
 }
@@ -353,10 +357,9 @@ func (p *PositionStruct) undoMakeMove() {
 
 //nullMove 走一步空步
 func (p *PositionStruct) nullMove() {
-	dwKey := p.zobr.dwKey
+	qwKey := p.zobr.qwKey
 	p.changeSide()
-	p.mvsList[p.nMoveNum].set(0, 0, false, dwKey)
-	p.nMoveNum++
+	p.pushMove(0, 0, false, qwKey)
 	p.nDistance++
 }
 
@@ -375,8 +378,8 @@ func (p *PositionStruct) nullOkay() bool {
 	return p.vlBlack > NullMargin
 }
 
-//generateMoves 生成所有走法，如果bCapture为true则只生成吃子走法
-func (p *PositionStruct) generateMoves(mvs []int, bCapture bool) int {
+//generateMovesMailbox 逐格扫描生成所有走法，如果bCapture为true则只生成吃子走法，用来校验位棋盘走法生成
+func (p *PositionStruct) generateMovesMailbox(mvs []int, bCapture bool) int {
 	nGenMoves, pcSrc, sqDst, pcDst, nDelta := 0, 0, 0, 0, 0
 	pcSelfSide := sideTag(p.sdPlayer)
 	pcOppSide := oppSideTag(p.sdPlayer)
@@ -607,8 +610,8 @@ func (p *PositionStruct) legalMove(mv int) bool {
 	return false
 }
 
-//checked 判断是否被将军
-func (p *PositionStruct) checked() bool {
+//checkedMailbox 逐格扫描判断是否被将军，用来校验位棋盘走法生成
+func (p *PositionStruct) checkedMailbox() bool {
 	nDelta, sqDst, pcDst := 0, 0, 0
 	pcSelfSide := sideTag(p.sdPlayer)
 	pcOppSide := oppSideTag(p.sdPlayer)
@@ -673,6 +676,34 @@ func (p *PositionStruct) checked() bool {
 	return false
 }
 
+//generateLegalMoves 生成所有合法走法(走完之后本方不被将军)，返回走法数
+func (p *PositionStruct) generateLegalMoves(mvs []int) int {
+	nLegalMoves, pcCaptured := 0, 0
+	nGenMoves := p.generateMoves(mvs, false)
+	for i := 0; i < nGenMoves; i++ {
+		pcCaptured = p.movePiece(mvs[i])
+		if !p.checked() {
+			mvs[nLegalMoves] = mvs[i]
+			nLegalMoves++
+		}
+		p.undoMovePiece(mvs[i], pcCaptured)
+	}
+	return nLegalMoves
+}
+
+//legalMovesFrom 生成某个格子上棋子的所有合法走法
+func (p *PositionStruct) legalMovesFrom(sq int) []int {
+	mvs := make([]int, MaxGenMoves)
+	nLegalMoves := p.generateLegalMoves(mvs)
+	result := []int{}
+	for i := 0; i < nLegalMoves; i++ {
+		if src(mvs[i]) == sq {
+			result = append(result, mvs[i])
+		}
+	}
+	return result
+}
+
 //isMate 判断是否被将死
 func (p *PositionStruct) isMate() bool {
 	pcCaptured := 0
@@ -699,18 +730,38 @@ func (p *PositionStruct) drawValue() int {
 	return DrawValue
 }
 
-//repStatus 检测重复局面
-func (p *PositionStruct) repStatus(nRecur int) int {
-	bSelfSide, bPerpCheck, bOppPerpCheck := false, true, true
-	lpmvs := [MaxMoves]*MoveStruct{}
-	for i := 0; i < MaxMoves; i++ {
-		lpmvs[i] = p.mvsList[i]
+//noCaptureMoves 上一次吃子之后走了多少步
+func (p *PositionStruct) noCaptureMoves() int {
+	nMoves := 0
+	i := p.nMoveNum - 1
+	for ; i > 0 && p.mvsList[i].ucpcCaptured == 0; i-- {
+		nMoves++
+	}
+	//起始局面之后一直没有吃子，还要加上起始局面之前的步数
+	if i == 0 {
+		nMoves += p.nStartClock
 	}
+	return nMoves
+}
+
+//gamePlies 对局的总步数，起始局面之前的步数按FEN中的回合数和起始局面的走子方计算
+func (p *PositionStruct) gamePlies() int {
+	nPlies := p.nMoveNum - 1
+	//起始局面轮到黑方走，说明这一回合红方已经走过了
+	sdStart := (p.sdPlayer + nPlies) % 2
+	return (p.nStartRound-1)*2 + sdStart + nPlies
+}
+
+//repStatus 检测重复局面，返回值：1=重复，2=本方长将，4=对方长将，8=本方长捉，16=对方长捉
+//检测长捉要重走整个循环，只在根节点和裁定对局结果时检测(bChase)，搜索中只检测重复和长将
+func (p *PositionStruct) repStatus(nRecur int, bChase bool) int {
+	bSelfSide, bPerpCheck, bOppPerpCheck := false, true, true
 
-	for i := p.nMoveNum - 1; i >= 0 && lpmvs[i].wmv != 0 && lpmvs[i].ucpcCaptured == 0; i-- {
+	//只需要往回查到上一次吃子或者空步为止
+	for i := p.nMoveNum - 1; i >= 0 && p.mvsList[i].wmv != 0 && p.mvsList[i].ucpcCaptured == 0; i-- {
 		if bSelfSide {
-			bPerpCheck = bPerpCheck && lpmvs[i].ucbCheck
-			if lpmvs[i].dwKey == p.zobr.dwKey {
+			bPerpCheck = bPerpCheck && p.mvsList[i].ucbCheck
+			if p.mvsList[i].qwKey == p.zobr.qwKey {
 				nRecur--
 				if nRecur == 0 {
 					result := 1
@@ -720,11 +771,21 @@ func (p *PositionStruct) repStatus(nRecur int) int {
 					if bOppPerpCheck {
 						result += 4
 					}
+					//双方都没有长将，再检测长捉
+					if bChase && !bPerpCheck && !bOppPerpCheck {
+						bPerpChase, bOppPerpChase := p.chaseStatus(i)
+						if bPerpChase {
+							result += 8
+						}
+						if bOppPerpChase {
+							result += 16
+						}
+					}
 					return result
 				}
 			}
 		} else {
-			bOppPerpCheck = bOppPerpCheck && lpmvs[i].ucbCheck
+			bOppPerpCheck = bOppPerpCheck && p.mvsList[i].ucbCheck
 		}
 		bSelfSide = !bSelfSide
 	}
@@ -740,6 +801,15 @@ func (p *PositionStruct) repValue(nRepStatus int) int {
 	if nRepStatus&4 != 0 {
 		vlReturn += BanValue - p.nDistance
 	}
+	//长将优先，双方都没有长将时长捉判负
+	if nRepStatus&6 == 0 {
+		if nRepStatus&8 != 0 {
+			vlReturn += p.nDistance - BanValue
+		}
+		if nRepStatus&16 != 0 {
+			vlReturn += BanValue - p.nDistance
+		}
+	}
 
 	if vlReturn == 0 {
 		return p.drawValue()
@@ -748,20 +818,21 @@ func (p *PositionStruct) repValue(nRepStatus int) int {
 	return vlReturn
 }
 
-//mirror 对局面镜像
-func (p *PositionStruct) mirror(posMirror *PositionStruct) {
-	pc := 0
-	posMirror.clearBoard()
-	for sq := 0; sq < 256; sq++ {
-		pc = p.ucpcSquares[sq]
-		if pc != 0 {
-			posMirror.addPiece(mirrorSquare(sq), pc)
-		}
+//mirrorLock 镜像局面的开局库校验码，不用创建整个镜像局面
+func (p *PositionStruct) mirrorLock() uint32 {
+	zobr := ZobristStruct{}
+	for bb := p.bbSides[0]; !bb.isEmpty(); {
+		sq := ccSquare256[bb.pop()]
+		zobr.xor1(&zobrist.Table[p.ucpcSquares[sq]-8][mirrorSquare(sq)])
+	}
+	for bb := p.bbSides[1]; !bb.isEmpty(); {
+		sq := ccSquare256[bb.pop()]
+		zobr.xor1(&zobrist.Table[p.ucpcSquares[sq]-9][mirrorSquare(sq)])
 	}
 	if p.sdPlayer == 1 {
-		posMirror.changeSide()
+		zobr.xor1(&zobrist.Player)
 	}
-	posMirror.setIrrev()
+	return zobr.bookLock()
 }
 
 //HashItem 置换表项结构
@@ -770,25 +841,39 @@ type HashItem struct {
 	ucFlag    int    //标志
 	svl       int    //分值
 	wmv       int    //最佳走法
-	dwLock0   uint32 //校验码
-	dwLock1   uint32 //校验码
+	qwLock    uint64 //校验码
 	wReserved int    //保留
 }
 
 //BookItem 开局库项结构
 type BookItem struct {
-	dwLock uint32 //局面 Zobrist 校验码中的 dwLock1
+	dwLock uint32 //局面 Zobrist 校验码中的 dwLock1，见bookLock
 	wmv    int    //走法
 	wvl    int    //是权重(随机选择走法的几率，仅当两个相同的 dwLock 有不同的 wmv 时，wvl 的值才有意义)
 }
 
 //Search 与搜索有关的全局变量
 type Search struct {
-	mvResult      int                 //电脑走的棋
-	nHistoryTable [65536]int          //历史表
-	mvKillers     [LimitDepth][2]int  //杀手走法表
-	hashTable     [HashSize]*HashItem //置换表
-	BookTable     []*BookItem         //开局库
+	mvResult      int                                 //电脑走的棋
+	nHistoryTable [65536]int                          //历史表
+	mvKillers     [LimitDepth][2]int                  //杀手走法表
+	hashTable     []HashItem                          //置换表
+	BookTable     []*BookItem                         //开局库
+	searchClock                                       //搜索限制
+	onInfo        func(SearchInfo)                    //报告搜索信息的回调，为nil时不报告
+	nMultiPV      int                                 //要搜索的主要变例数，大于1时不加随机性分值
+	lines         []SearchInfo                        //最后一次完整迭代的各条主要变例，按名次排列
+	mvsPV         [LimitDepth + 1][LimitDepth + 1]int //每一层的主要变例
+	nPVLength     [LimitDepth + 1]int                 //每一层主要变例的长度
+	rnd           *rand.Rand                          //开局库和随机性分值用的随机数，各个搜索对象互不影响
+}
+
+//newSearch 创建搜索用的表，置换表一次分配
+func newSearch() *Search {
+	return &Search{
+		hashTable: make([]HashItem, HashSize),
+		rnd:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
 }
 
 //searchBook 搜索开局库
@@ -805,7 +890,7 @@ func (p *PositionStruct) searchBook() int {
 
 	//搜索当前局面
 	bMirror := false
-	bkToSearch.dwLock = p.zobr.dwLock1
+	bkToSearch.dwLock = p.zobr.bookLock()
 	lpbk := sort.Search(bookSize, func(i int) bool {
 		return p.search.BookTable[i].dwLock >= bkToSearch.dwLock
 	})
@@ -813,9 +898,7 @@ func (p *PositionStruct) searchBook() int {
 	//如果没有找到，那么搜索当前局面的镜像局面
 	if lpbk == bookSize || (lpbk < bookSize && p.search.BookTable[lpbk].dwLock != bkToSearch.dwLock) {
 		bMirror = true
-		posMirror := NewPositionStruct()
-		p.mirror(posMirror)
-		bkToSearch.dwLock = posMirror.zobr.dwLock1
+		bkToSearch.dwLock = p.mirrorLock()
 		lpbk = sort.Search(bookSize, func(i int) bool {
 			return p.search.BookTable[i].dwLock >= bkToSearch.dwLock
 		})
@@ -854,7 +937,7 @@ func (p *PositionStruct) searchBook() int {
 		return 0
 	}
 	//根据权重随机选择一个走法
-	vl = rand.Intn(vl)
+	vl = p.search.rnd.Intn(vl)
 	i := 0
 	for i = 0; i < nBookMoves; i++ {
 		vl -= vls[i]
@@ -867,8 +950,8 @@ func (p *PositionStruct) searchBook() int {
 
 //probeHash 提取置换表项
 func (p *PositionStruct) probeHash(vlAlpha, vlBeta, nDepth int) (int, int) {
-	hsh := p.search.hashTable[p.zobr.dwKey&(HashSize-1)]
-	if hsh.dwLock0 != p.zobr.dwLock0 || hsh.dwLock1 != p.zobr.dwLock1 {
+	hsh := p.search.hashTable[p.zobr.hashIndex()]
+	if hsh.qwLock != p.zobr.qwKey {
 		return -MateValue, 0
 	}
 	mv := hsh.wmv
@@ -907,7 +990,7 @@ func (p *PositionStruct) probeHash(vlAlpha, vlBeta, nDepth int) (int, int) {
 
 //RecordHash 保存置换表项
 func (p *PositionStruct) RecordHash(nFlag, vl, nDepth, mv int) {
-	hsh := p.search.hashTable[p.zobr.dwKey&(HashSize-1)]
+	hsh := &p.search.hashTable[p.zobr.hashIndex()]
 	if hsh.ucDepth > nDepth {
 		return
 	}
@@ -928,9 +1011,7 @@ func (p *PositionStruct) RecordHash(nFlag, vl, nDepth, mv int) {
 		hsh.svl = vl
 	}
 	hsh.wmv = mv
-	hsh.dwLock0 = p.zobr.dwLock0
-	hsh.dwLock1 = p.zobr.dwLock1
-	p.search.hashTable[p.zobr.dwKey&(HashSize-1)] = hsh
+	hsh.qwLock = p.zobr.qwKey
 }
 
 //mvvLva 求MVV/LVA值
@@ -1028,9 +1109,15 @@ func (p *PositionStruct) setBestMove(mv, nDepth int) {
 func (p *PositionStruct) searchQuiesc(vlAlpha, vlBeta int) int {
 	nGenMoves := 0
 	mvs := make([]int, MaxGenMoves)
+	p.search.nPVLength[p.nDistance] = 0
+
+	//超出搜索限制就立即返回，返回值不会被使用
+	if p.stopped() {
+		return 0
+	}
 
 	//检查重复局面
-	vl := p.repStatus(1)
+	vl := p.repStatus(1, p.nDistance == 1)
 	if vl != 0 {
 		return p.repValue(vl)
 	}
@@ -1075,6 +1162,9 @@ func (p *PositionStruct) searchQuiesc(vlAlpha, vlBeta int) int {
 		if p.makeMove(mvs[i]) {
 			vl = -p.searchQuiesc(-vlBeta, -vlAlpha)
 			p.undoMakeMove()
+			if p.search.bStop {
+				return 0
+			}
 
 			//进行Alpha-Beta大小判断和截断
 			if vl > vlBest {
@@ -1090,6 +1180,7 @@ func (p *PositionStruct) searchQuiesc(vlAlpha, vlBeta int) int {
 				if vl > vlAlpha {
 					//缩小Alpha-Beta边界
 					vlAlpha = vl
+					p.updatePV(mvs[i])
 				}
 			}
 		}
@@ -1105,14 +1196,20 @@ func (p *PositionStruct) searchQuiesc(vlAlpha, vlBeta int) int {
 //searchFull 超出边界(Fail-Soft)的Alpha-Beta搜索过程
 func (p *PositionStruct) searchFull(vlAlpha, vlBeta, nDepth int, bNoNull bool) int {
 	vl, mvHash, nNewDepth := 0, 0, 0
+	p.search.nPVLength[p.nDistance] = 0
 
 	//到达水平线，则调用静态搜索(注意：由于空步裁剪，深度可能小于零)
 	if nDepth <= 0 {
 		return p.searchQuiesc(vlAlpha, vlBeta)
 	}
 
+	//超出搜索限制就立即返回，返回值不会被使用
+	if p.stopped() {
+		return 0
+	}
+
 	//检查重复局面(注意：不要在根节点检查，否则就没有走法了)
-	vl = p.repStatus(1)
+	vl = p.repStatus(1, p.nDistance == 1)
 	if vl != 0 {
 		return p.repValue(vl)
 	}
@@ -1133,6 +1230,9 @@ func (p *PositionStruct) searchFull(vlAlpha, vlBeta, nDepth int, bNoNull bool) i
 		p.nullMove()
 		vl = -p.searchFull(-vlBeta, 1-vlBeta, nDepth-NullDepth-1, true)
 		p.undoNullMove()
+		if p.search.bStop {
+			return 0
+		}
 		if vl >= vlBeta {
 			return vl
 		}
@@ -1170,6 +1270,9 @@ func (p *PositionStruct) searchFull(vlAlpha, vlBeta, nDepth int, bNoNull bool) i
 				}
 			}
 			p.undoMakeMove()
+			if p.search.bStop {
+				return 0
+			}
 
 			//进行Alpha-Beta大小判断和截断
 			if vl > vlBest {
@@ -1187,6 +1290,7 @@ func (p *PositionStruct) searchFull(vlAlpha, vlBeta, nDepth int, bNoNull bool) i
 					nHashFlag = HashPV
 					mvBest = mv
 					vlAlpha = vl
+					p.updatePV(mv)
 				}
 			}
 		}
@@ -1206,10 +1310,34 @@ func (p *PositionStruct) searchFull(vlAlpha, vlBeta, nDepth int, bNoNull bool) i
 	return vlBest
 }
 
-//searchRoot 根节点的Alpha-Beta搜索过程
+//searchRoot 根节点的搜索过程，多主要变例时搜索nMultiPV遍，每遍排除前面几遍的最佳走法，结果按名次记录到lines
 func (p *PositionStruct) searchRoot(nDepth int) int {
-	vl, nNewDepth := 0, 0
+	lines := make([]SearchInfo, 0, p.search.nMultiPV)
+	mvsExclude := make([]int, 0, p.search.nMultiPV)
+	for len(lines) < p.search.nMultiPV {
+		vl, mv := p.searchRootMoves(nDepth, mvsExclude)
+		if p.search.bStop {
+			return 0
+		}
+		//没有更多的走法了，第一遍就没有走法说明已经被杀
+		if mv == 0 && len(lines) > 0 {
+			break
+		}
+		lines = append(lines, p.newInfo(nDepth, len(lines)+1, vl))
+		if mv == 0 {
+			break
+		}
+		mvsExclude = append(mvsExclude, mv)
+	}
+	p.search.lines = lines
+	return lines[0].Score
+}
+
+//searchRootMoves 根节点的Alpha-Beta搜索过程，跳过mvsExclude中的走法，返回最佳分值和最佳走法
+func (p *PositionStruct) searchRootMoves(nDepth int, mvsExclude []int) (int, int) {
+	vl, nNewDepth, mvBest := 0, 0, 0
 	vlBest := -MateValue
+	p.search.nPVLength[0] = 0
 
 	//初始化走法排序结构
 	tmpSort := &SortStruct{
@@ -1219,6 +1347,9 @@ func (p *PositionStruct) searchRoot(nDepth int) int {
 
 	//逐一走这些走法，并进行递归
 	for mv := p.nextSort(tmpSort); mv != 0; mv = p.nextSort(tmpSort) {
+		if excludedMove(mvsExclude, mv) {
+			continue
+		}
 		if p.makeMove(mv) {
 			if p.inCheck() {
 				nNewDepth = nDepth
@@ -1234,22 +1365,46 @@ func (p *PositionStruct) searchRoot(nDepth int) int {
 				}
 			}
 			p.undoMakeMove()
+			//没搜索完的走法不能用
+			if p.search.bStop {
+				return vlBest, mvBest
+			}
 			if vl > vlBest {
 				vlBest = vl
-				p.search.mvResult = mv
-				if vlBest > -WinValue && vlBest < WinValue {
-					vlBest += int(rand.Int31()&RandomMask) - int(rand.Int31()&RandomMask)
+				mvBest = mv
+				p.updatePV(mv)
+				//只有第一遍的结果才是电脑走的棋
+				if len(mvsExclude) == 0 {
+					p.search.mvResult = mv
+				}
+				//多主要变例时不加随机性分值，以免名次不稳定
+				if p.search.nMultiPV == 1 && vlBest > -WinValue && vlBest < WinValue {
+					vlBest += int(p.search.rnd.Int31()&RandomMask) - int(p.search.rnd.Int31()&RandomMask)
 				}
+				p.reportInfo(p.newInfo(nDepth, len(mvsExclude)+1, vlBest))
 			}
 		}
 	}
-	p.RecordHash(HashPV, vlBest, nDepth, p.search.mvResult)
-	p.setBestMove(p.search.mvResult, nDepth)
-	return vlBest
+	//排除了走法的结果不能记录到置换表
+	if len(mvsExclude) == 0 {
+		p.RecordHash(HashPV, vlBest, nDepth, p.search.mvResult)
+		p.setBestMove(p.search.mvResult, nDepth)
+	}
+	return vlBest, mvBest
 }
 
-//searchMain 迭代加深搜索过程
-func (p *PositionStruct) searchMain() {
+//excludedMove 走法mv是否在mvs中
+func excludedMove(mvs []int, mv int) bool {
+	for _, mvExclude := range mvs {
+		if mvExclude == mv {
+			return true
+		}
+	}
+	return false
+}
+
+//searchMain 迭代加深搜索过程，超出limits或ctx取消时停止
+func (p *PositionStruct) searchMain(ctx context.Context, limits SearchLimits) {
 	//清空历史表
 	for i := 0; i < 65536; i++ {
 		p.search.nHistoryTable[i] = 0
@@ -1261,26 +1416,29 @@ func (p *PositionStruct) searchMain() {
 		}
 	}
 	//清空置换表
-	for i := 0; i < HashSize; i++ {
-		p.search.hashTable[i].ucDepth = 0
-		p.search.hashTable[i].ucFlag = 0
-		p.search.hashTable[i].svl = 0
-		p.search.hashTable[i].wmv = 0
-		p.search.hashTable[i].wReserved = 0
-		p.search.hashTable[i].dwLock0 = 0
-		p.search.hashTable[i].dwLock1 = 0
-	}
-	//初始化定时器
-	start := time.Now()
+	for i := range p.search.hashTable {
+		p.search.hashTable[i] = HashItem{}
+	}
+	//初始化定时器和搜索限制，至少搜索一条主要变例
+	p.startLimits(ctx, limits)
+	if p.search.nMultiPV < 1 {
+		p.search.nMultiPV = 1
+	}
 	//初始步数
 	p.nDistance = 0
 
-	//搜索开局库
-	p.search.mvResult = p.searchBook()
+	//搜索开局库(无限分析和多主要变例时不用)
+	bAnalyze := limits.Infinite || p.search.nMultiPV > 1
+	p.search.mvResult = 0
+	p.search.lines = nil
+	if !bAnalyze {
+		p.search.mvResult = p.searchBook()
+	}
 	if p.search.mvResult != 0 {
 		p.makeMove(p.search.mvResult)
-		if p.repStatus(3) == 0 {
+		if p.repStatus(3, false) == 0 {
 			p.undoMakeMove()
+			p.search.lines = []SearchInfo{p.singleInfo()}
 			return
 		}
 		p.undoMakeMove()
@@ -1297,23 +1455,34 @@ func (p *PositionStruct) searchMain() {
 			vl++
 		}
 	}
-	if vl == 1 {
+	if vl == 1 && !bAnalyze {
+		p.search.lines = []SearchInfo{p.singleInfo()}
 		return
 	}
 
 	//迭代加深过程
-	rand.Seed(time.Now().UnixNano())
-	for i := 1; i <= LimitDepth; i++ {
+	for i := 1; i <= limits.maxDepth(); i++ {
 		vl = p.searchRoot(i)
-		//搜索到杀棋，就终止搜索
-		if vl > WinValue || vl < -WinValue {
-			break
+		//超出搜索限制或被取消，就用上一次完整迭代的结果
+		if p.search.bStop {
+			if len(p.search.lines) > 0 && p.search.lines[0].Depth > 0 {
+				p.search.mvResult = int(p.search.lines[0].BestMove)
+			} else {
+				p.search.lines = []SearchInfo{p.singleInfo()}
+			}
+			return
 		}
-		//超过一秒，就终止搜索
-		if time.Now().Sub(start).Milliseconds() > 1000 {
+		for _, info := range p.search.lines {
+			p.reportInfo(info)
+		}
+		//搜索到杀棋，就终止搜索(无限分析时继续)
+		if !limits.Infinite && (vl > WinValue || vl < -WinValue) {
 			break
 		}
 	}
+
+	//无限分析到了极限深度，也要等到被取消才返回
+	p.search.waitCancel()
 }
 
 //printBoard 打印棋盘
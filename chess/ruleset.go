@@ -0,0 +1,48 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 和棋规则
+ */
+
+package chess
+
+//Ruleset 和棋规则，回合数为0表示不限制
+type Ruleset struct {
+	NoCaptureLimit       int  //连续多少回合没有吃子判和
+	GameLengthLimit      int  //对局超过多少回合判和
+	InsufficientMaterial bool //双方都没有进攻棋子(马、车、炮、兵)时判和
+}
+
+//DefaultRuleset 默认规则：60回合没有吃子判和，不限制对局长度，双方都没有进攻棋子时判和
+var DefaultRuleset = Ruleset{
+	NoCaptureLimit:       60,
+	GameLengthLimit:      0,
+	InsufficientMaterial: true,
+}
+
+//noAttackers 双方是否都没有进攻棋子(马、车、炮、兵)
+func (p *PositionStruct) noAttackers() bool {
+	for sd := 0; sd < 2; sd++ {
+		for _, pt := range [...]int{PieceMa, PieceJu, PiecePao, PieceBing} {
+			if !p.bbPieces[sd][pt].isEmpty() {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+//ruleDraw 根据规则判断是否和棋，返回判和的原因，nNoCapture是没有吃子的步数，nPlies是对局的总步数(包括起始局面之前的步数)
+func (p *PositionStruct) ruleDraw(r Ruleset, nNoCapture, nPlies int) Reason {
+	if r.InsufficientMaterial && p.noAttackers() {
+		return ReasonInsufficientMaterial
+	}
+	if r.NoCaptureLimit > 0 && nNoCapture >= r.NoCaptureLimit*2 {
+		return ReasonMoveLimit
+	}
+	if r.GameLengthLimit > 0 && nPlies >= r.GameLengthLimit*2 {
+		return ReasonMoveLimit
+	}
+	return ReasonNone
+}
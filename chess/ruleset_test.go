@@ -0,0 +1,92 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 和棋规则测试
+ */
+
+package chess
+
+import (
+	"testing"
+)
+
+//TestRuleDrawStopsPlay 按规则判和之后不能再走棋
+func TestRuleDrawStopsPlay(t *testing.T) {
+	p := NewPosition()
+	p.SetRuleset(Ruleset{GameLengthLimit: 2})
+	for _, str := range []string{"h2e2", "h9g7", "h0g2", "i9h9"} {
+		mv, _ := ParseMove(str)
+		if err := p.Play(mv); err != nil {
+			t.Fatalf("%s: %v", str, err)
+		}
+	}
+	if r := p.Result(); r != drawResult(ReasonMoveLimit) {
+		t.Fatalf("result %v", r)
+	}
+	mv, _ := ParseMove("i0h0")
+	if err := p.Play(mv); err != ErrGameOver {
+		t.Errorf("play after move limit: got %v, want %v", err, ErrGameOver)
+	}
+}
+
+//TestNoCaptureClockFromFEN FEN中没有吃子的步数也要计入没有吃子的回合数限制
+func TestNoCaptureClockFromFEN(t *testing.T) {
+	p, err := NewPositionFromFEN("3k5/9/n8/9/9/9/9/9/2R6/4K4 w - - 119 80")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := p.Result(); r.IsOver() {
+		t.Fatalf("result %v", r)
+	}
+	mv, _ := ParseMove("c1c2")
+	if err := p.Play(mv); err != nil {
+		t.Fatal(err)
+	}
+	if r := p.Result(); r != drawResult(ReasonMoveLimit) {
+		t.Errorf("result %v, want %v", r, drawResult(ReasonMoveLimit))
+	}
+
+	//写出之后再读入，还是同样的结果
+	q, err := NewPositionFromFEN(p.FEN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := q.Result(); r != drawResult(ReasonMoveLimit) {
+		t.Errorf("reloaded result %v, want %v", r, drawResult(ReasonMoveLimit))
+	}
+}
+
+//TestGameLengthFromFEN 对局长度限制也要计入FEN中起始局面之前的回合数
+func TestGameLengthFromFEN(t *testing.T) {
+	cases := []struct {
+		fen string
+		mvs []string //走到判和需要走的棋
+	}{
+		//第50回合红方走，还要走两步才满50回合
+		{"3k5/9/n8/9/9/9/9/9/2R6/4K4 w - - 0 50", []string{"c1c2", "a7b9"}},
+		//第50回合黑方走，还要走一步
+		{"3k5/9/n8/9/9/9/9/9/2R6/4K4 b - - 0 50", []string{"a7b9"}},
+		//第48回合红方走，还要走六步
+		{"3k5/9/n8/9/9/9/9/9/2R6/4K4 w - - 0 48", []string{"c1c2", "a7b9", "c2c3", "b9a7", "c3c4", "a7b9"}},
+	}
+	for _, c := range cases {
+		p, err := NewPositionFromFEN(c.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.SetRuleset(Ruleset{GameLengthLimit: 50})
+		for i, str := range c.mvs {
+			if r := p.Result(); r.IsOver() {
+				t.Fatalf("%q: result %v after %d plies", c.fen, r, i)
+			}
+			mv, _ := ParseMove(str)
+			if err := p.Play(mv); err != nil {
+				t.Fatalf("%q %s: %v", c.fen, str, err)
+			}
+		}
+		if r := p.Result(); r != drawResult(ReasonMoveLimit) {
+			t.Errorf("%q: result %v, want %v", c.fen, r, drawResult(ReasonMoveLimit))
+		}
+	}
+}
@@ -0,0 +1,176 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 局面合法性检查
+ */
+
+package chess
+
+import (
+	"fmt"
+	"strings"
+)
+
+//ViolationCode 局面违规的类型
+type ViolationCode int
+
+const (
+	//ViolationKingCount 帅(将)不是正好一个
+	ViolationKingCount ViolationCode = iota + 1
+	//ViolationPieceCount 某种棋子超过了初始数量
+	ViolationPieceCount
+	//ViolationKingSquare 帅(将)不在九宫
+	ViolationKingSquare
+	//ViolationShiSquare 仕(士)不在九宫的五个点上
+	ViolationShiSquare
+	//ViolationXiangSquare 相(象)不在本方的七个点上
+	ViolationXiangSquare
+	//ViolationBingSquare 兵(卒)在初始位置的后面，或者未过河时不在初始的纵线上
+	ViolationBingSquare
+	//ViolationOppChecked 不走棋的一方被将军(包括将帅对脸)，走子方可以直接吃掉帅(将)
+	ViolationOppChecked
+)
+
+//cszViolation 违规类型的说明
+var cszViolation = map[ViolationCode]string{
+	ViolationKingCount:   "king count is not one",
+	ViolationPieceCount:  "too many pieces",
+	ViolationKingSquare:  "king outside palace",
+	ViolationShiSquare:   "advisor on illegal square",
+	ViolationXiangSquare: "elephant on illegal square",
+	ViolationBingSquare:  "pawn on illegal square",
+	ViolationOppChecked:  "side not to move is in check",
+}
+
+//String 违规类型的说明
+func (c ViolationCode) String() string {
+	if str, ok := cszViolation[c]; ok {
+		return str
+	}
+	return fmt.Sprintf("violation %d", int(c))
+}
+
+//Violation 一处局面违规
+type Violation struct {
+	Code   ViolationCode //违规类型
+	Square int           //违规的格子，和格子无关时为0
+	Piece  int           //违规的棋子，和棋子无关时为0
+}
+
+//String 违规的说明
+func (v Violation) String() string {
+	str := v.Code.String()
+	if v.Piece != 0 {
+		str += fmt.Sprintf(" (%c", pieceFEN(v.Piece))
+		if v.Square != 0 {
+			str += " on " + squareToICCS(v.Square)
+		}
+		str += ")"
+	}
+	return str
+}
+
+//ValidationError 局面不合法时返回的错误，包括所有违规
+type ValidationError []Violation
+
+//Error 错误说明
+func (e ValidationError) Error() string {
+	strs := make([]string, len(e))
+	for i, v := range e {
+		strs[i] = v.String()
+	}
+	return "chess: invalid position: " + strings.Join(strs, "; ")
+}
+
+//ccMaxPieces 每种棋子的最多数量，顺序同PieceJiang...PieceBing
+var ccMaxPieces = [7]int{1, 2, 2, 2, 2, 2, 5}
+
+//ccShiSquares 红方仕能到达的格子，黑方取翻转格子
+var ccShiSquares = []int{0xa6, 0xa8, 0xb7, 0xc6, 0xc8}
+
+//ccXiangSquares 红方相能到达的格子，黑方取翻转格子
+var ccXiangSquares = []int{0x85, 0x89, 0xa3, 0xa7, 0xab, 0xc5, 0xc9}
+
+//containsSquare 格子是否在列表中
+func containsSquare(sqs []int, sq int) bool {
+	for _, v := range sqs {
+		if v == sq {
+			return true
+		}
+	}
+	return false
+}
+
+//validSquare 棋子能否出现在格子上
+func validSquare(sq, pc int) bool {
+	//黑方棋子翻转成红方棋子判断
+	pt := pc - sideTag(0)
+	if pc >= 16 {
+		pt = pc - sideTag(1)
+		sq = squareFlip(sq)
+	}
+	switch pt {
+	case PieceJiang:
+		return inFort(sq)
+	case PieceShi:
+		return containsSquare(ccShiSquares, sq)
+	case PieceXiang:
+		return containsSquare(ccXiangSquares, sq)
+	case PieceBing:
+		//过河以前只能向前走，所以不能在初始位置后面，也不能离开初始的纵线
+		if hasRiver(sq, 0) {
+			return true
+		}
+		return getY(sq) <= Bottom-3 && (getX(sq)-Left)&1 == 0
+	}
+	return true
+}
+
+//ccSquareViolation 每种棋子不在合法格子上的违规类型，顺序同PieceJiang...PieceBing
+var ccSquareViolation = [7]ViolationCode{
+	ViolationKingSquare, ViolationShiSquare, ViolationXiangSquare, 0, 0, 0, ViolationBingSquare}
+
+//validate 检查局面是否合法，返回所有违规
+func (p *PositionStruct) validate() []Violation {
+	vs := []Violation{}
+	nPieces := [24]int{}
+	for sq := 0; sq < 256; sq++ {
+		pc := p.ucpcSquares[sq]
+		if pc == 0 {
+			continue
+		}
+		nPieces[pc]++
+		if validSquare(sq, pc) {
+			continue
+		}
+		vs = append(vs, Violation{Code: ccSquareViolation[pc&7], Square: sq, Piece: pc})
+	}
+
+	//棋子数量
+	for sd := 0; sd < 2; sd++ {
+		for pt := PieceJiang; pt <= PieceBing; pt++ {
+			pc := sideTag(sd) + pt
+			if pt == PieceJiang && nPieces[pc] != 1 {
+				vs = append(vs, Violation{Code: ViolationKingCount, Piece: pc})
+			} else if nPieces[pc] > ccMaxPieces[pt] {
+				vs = append(vs, Violation{Code: ViolationPieceCount, Piece: pc})
+			}
+		}
+	}
+
+	//不走棋的一方不能被将军
+	if nPieces[sideTag(0)+PieceJiang] > 0 && nPieces[sideTag(1)+PieceJiang] > 0 {
+		p.changeSide()
+		if p.checked() {
+			vs = append(vs, Violation{Code: ViolationOppChecked})
+		}
+		p.changeSide()
+	}
+	return vs
+}
+
+//Validate 检查局面是否合法，返回所有违规，合法时返回空列表
+func (p *Position) Validate() []Violation {
+	return p.pos.validate()
+}
@@ -0,0 +1,86 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 局面合法性检查测试
+ */
+
+package chess
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+//TestValidate 每种违规的局面都被NewPositionFromFEN拒绝，并报告违规的格子和棋子
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name string
+		fen  string
+		want Violation
+	}{
+		{"two red kings", "5k3/9/9/9/9/9/9/9/9/3KK4 w - - 0 1",
+			Violation{Code: ViolationKingCount, Piece: 8 + PieceJiang}},
+		{"no black king", "9/9/9/9/9/9/9/9/9/4K4 w - - 0 1",
+			Violation{Code: ViolationKingCount, Piece: 16 + PieceJiang}},
+		{"three red rooks", "3k5/9/9/9/9/9/9/9/9/RRR1K4 w - - 0 1",
+			Violation{Code: ViolationPieceCount, Piece: 8 + PieceJu}},
+		{"six black pawns", "3k5/9/9/p1p1p1p1p/p8/9/9/9/9/4K4 w - - 0 1",
+			Violation{Code: ViolationPieceCount, Piece: 16 + PieceBing}},
+		{"red king outside palace", "3k5/9/9/9/9/9/9/9/9/K8 w - - 0 1",
+			Violation{Code: ViolationKingSquare, Square: SquareXY(Left, Bottom), Piece: 8 + PieceJiang}},
+		{"black king outside palace", "9/9/9/9/9/9/9/k8/9/4K4 w - - 0 1",
+			Violation{Code: ViolationKingSquare, Square: SquareXY(Left, Bottom-2), Piece: 16 + PieceJiang}},
+		{"advisor outside palace", "3k5/9/9/9/9/9/9/9/9/A3K4 w - - 0 1",
+			Violation{Code: ViolationShiSquare, Square: SquareXY(Left, Bottom), Piece: 8 + PieceShi}},
+		{"elephant off its points", "3k5/9/9/9/9/9/9/9/9/4K3B w - - 0 1",
+			Violation{Code: ViolationXiangSquare, Square: SquareXY(Right, Bottom), Piece: 8 + PieceXiang}},
+		{"black elephant across the river", "3k5/9/9/9/9/9/9/4b4/9/5K3 w - - 0 1",
+			Violation{Code: ViolationXiangSquare, Square: SquareXY(Left+4, Bottom-2), Piece: 16 + PieceXiang}},
+		{"pawn behind its start", "3k5/9/9/9/9/9/9/9/P8/4K4 w - - 0 1",
+			Violation{Code: ViolationBingSquare, Square: SquareXY(Left, Bottom-1), Piece: 8 + PieceBing}},
+		{"pawn off its file before the river", "3k5/9/9/9/9/9/1P7/9/9/4K4 w - - 0 1",
+			Violation{Code: ViolationBingSquare, Square: SquareXY(Left+1, Bottom-3), Piece: 8 + PieceBing}},
+		{"side not to move in check", "4k4/9/9/9/9/9/9/9/4R4/3K5 w - - 0 1",
+			Violation{Code: ViolationOppChecked}},
+		{"facing kings", "4k4/9/9/9/9/9/9/9/9/4K4 w - - 0 1",
+			Violation{Code: ViolationOppChecked}},
+	}
+	for _, c := range cases {
+		_, err := NewPositionFromFEN(c.fen)
+		var ve ValidationError
+		if !errors.As(err, &ve) {
+			t.Errorf("%s: got %v, want ValidationError", c.name, err)
+			continue
+		}
+		bFound := false
+		for _, v := range ve {
+			bFound = bFound || v == c.want
+		}
+		if !bFound {
+			t.Errorf("%s: got %v, want %v", c.name, ve, c.want)
+		}
+		if !strings.Contains(err.Error(), c.want.Code.String()) {
+			t.Errorf("%s: error %q does not mention %q", c.name, err, c.want.Code)
+		}
+	}
+}
+
+//TestValidateLegal 合法的局面没有违规，包括过河的兵和走子方被将军
+func TestValidateLegal(t *testing.T) {
+	for _, fen := range []string{
+		StartupFEN,
+		"3k5/9/9/9/1P7/9/9/9/9/4K4 w - - 0 1",
+		"4k4/9/9/9/9/9/9/9/4R4/3K5 b - - 0 1",
+	} {
+		p, err := NewPositionFromFEN(fen)
+		if err != nil {
+			t.Errorf("%q: %v", fen, err)
+			continue
+		}
+		if vs := p.Validate(); len(vs) != 0 {
+			t.Errorf("%q: %v", fen, vs)
+		}
+	}
+}
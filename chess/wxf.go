@@ -0,0 +1,127 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * WXF记谱
+ */
+
+package chess
+
+import (
+	"fmt"
+	"strings"
+)
+
+//cszPieceWXF WXF记谱的棋子字母，顺序同PieceJiang...PieceBing
+const cszPieceWXF = "KAEHRCP"
+
+//wxfPrefix 获得同一纵线上多个同类棋子的前后标记
+func wxfPrefix(nCount, nIndex int) byte {
+	switch {
+	case nCount > 3:
+		return byte('1' + nIndex)
+	case nIndex == 0:
+		return '+'
+	case nIndex == nCount-1:
+		return '-'
+	}
+	return '='
+}
+
+//wxf 把纵线描述转换成WXF记谱
+func (n NotationStruct) wxf() string {
+	str := make([]byte, 0, 4)
+	if n.nCount > 1 {
+		str = append(str, wxfPrefix(n.nCount, n.nIndex))
+		if n.bTandem {
+			str = append(str, byte('0'+n.nFile))
+		} else {
+			str = append(str, cszPieceWXF[n.pt])
+		}
+	} else {
+		str = append(str, cszPieceWXF[n.pt], byte('0'+n.nFile))
+	}
+	switch n.nDir {
+	case 1:
+		str = append(str, '+')
+	case -1:
+		str = append(str, '-')
+	default:
+		str = append(str, '.')
+	}
+	return string(append(str, byte('0'+n.nNum)))
+}
+
+//moveToWXF 把走法转换成WXF记谱，例如"C2.5"，走法必须是走子方的合法走法
+func (p *PositionStruct) moveToWXF(mv int) (string, error) {
+	if err := p.checkNotationMove(mv); err != nil {
+		return "", err
+	}
+	n, err := p.describeMove(mv)
+	if err != nil {
+		return "", err
+	}
+	return n.wxf(), nil
+}
+
+//wxfLetter 规范WXF棋子字母，兼容小写以及B(相)、N(马)的写法
+func wxfLetter(c byte) byte {
+	c = strings.ToUpper(string(c))[0]
+	switch c {
+	case 'B':
+		return 'E'
+	case 'N':
+		return 'H'
+	}
+	return c
+}
+
+//normalizeWXF 规范WXF记谱，以便和生成的记谱比较
+func normalizeWXF(str string) (string, error) {
+	s := []byte(strings.TrimSpace(str))
+	if len(s) != 4 {
+		return "", fmt.Errorf("wxf: invalid move %q", str)
+	}
+
+	//第一个字符是棋子、前后标记或者序号
+	s[0] = wxfLetter(s[0])
+	if strings.IndexByte(cszPieceWXF+"+-=12345", s[0]) < 0 {
+		return "", fmt.Errorf("wxf: invalid move %q", str)
+	}
+
+	//第二个字符是纵线或者棋子
+	s[1] = wxfLetter(s[1])
+	if (s[1] < '1' || s[1] > '9') && strings.IndexByte(cszPieceWXF, s[1]) < 0 {
+		return "", fmt.Errorf("wxf: invalid move %q", str)
+	}
+
+	//第三个字符是方向，兼容用"="表示平
+	if s[2] == '=' {
+		s[2] = '.'
+	}
+	if s[2] != '+' && s[2] != '-' && s[2] != '.' {
+		return "", fmt.Errorf("wxf: invalid move %q", str)
+	}
+
+	//第四个字符是数字
+	if s[3] < '1' || s[3] > '9' {
+		return "", fmt.Errorf("wxf: invalid move %q", str)
+	}
+	return string(s), nil
+}
+
+//wxfToMove 把WXF记谱转换成走法，只接受合法的走法
+func (p *PositionStruct) wxfToMove(str string) (int, error) {
+	strMove, err := normalizeWXF(str)
+	if err != nil {
+		return 0, err
+	}
+	mvs := make([]int, MaxGenMoves)
+	nLegalMoves := p.generateLegalMoves(mvs)
+	for i := 0; i < nLegalMoves; i++ {
+		if n, err := p.describeMove(mvs[i]); err == nil && n.wxf() == strMove {
+			return mvs[i], nil
+		}
+	}
+	return 0, fmt.Errorf("wxf: no legal move %q", str)
+}
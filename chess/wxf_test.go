@@ -0,0 +1,126 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * WXF记谱测试
+ */
+
+package chess
+
+import (
+	"testing"
+)
+
+//wxfCases WXF记谱用例，局面同中文记谱的用例
+var wxfCases = []notationCase{
+	{"", nil, "h2e2", "C2.5"},
+	{"", nil, "b0c2", "H8+7"},
+	{"", nil, "a0a1", "R9+1"},
+	{"", nil, "c0e2", "E7+5"},
+	{"", nil, "f0e1", "A4+5"},
+	{"", nil, "e3e4", "P5+1"},
+	{"", []string{"h2e2"}, "h7e7", "C8.5"},
+	{"", []string{"h2e2"}, "h9g7", "H8+7"},
+	{"", []string{"h2e2"}, "c6c5", "P3+1"},
+	{"", []string{"h2e2"}, "e9e8", "K5+1"},
+	{"", []string{"h2e2"}, "h7h8", "C8-1"},
+	{fenTwoRooks, nil, "a3a4", "+R+1"},
+	{fenTwoRooks, nil, "a0a1", "-R+1"},
+	{fenTwoRooks, nil, "a3b3", "+R.8"},
+	{fenTwoRooks, nil, "a0b0", "-R.8"},
+	{fenThreePawns, nil, "e7e8", "+P+1"},
+	{fenThreePawns, nil, "e6d6", "=P.6"},
+	{fenThreePawns, nil, "e5f5", "-P.4"},
+	{fenFourPawns, nil, "e8d8", "1P.6"},
+	{fenFourPawns, nil, "e7d7", "2P.6"},
+	{fenFourPawns, nil, "e6f6", "3P.4"},
+	{fenFourPawns, nil, "e5d5", "4P.6"},
+	{fenTandemPawns, nil, "c6c7", "+7+1"},
+	{fenTandemPawns, nil, "g5h5", "-3.2"},
+	{fenBlackPieces, nil, "c3c2", "+3+1"},
+	{fenBlackPieces, nil, "g4h4", "-7.8"},
+	{fenBlackPieces, nil, "d1d2", "+R-1"},
+	{fenBlackPieces, nil, "d3e3", "-R.5"},
+}
+
+//TestWXF 生成WXF记谱，再解析回原来的走法
+func TestWXF(t *testing.T) {
+	for _, c := range wxfCases {
+		p := notationPosition(t, c.fen, c.before)
+		mv, _ := ParseMove(c.iccs)
+		str, err := p.WXF(mv)
+		if err != nil || str != c.want {
+			t.Errorf("%q %s: got %q, %v, want %q", c.fen, c.iccs, str, err, c.want)
+			continue
+		}
+		if mvParsed, err := p.ParseWXF(str); err != nil || mvParsed != mv {
+			t.Errorf("%q %s: ParseWXF(%q) = %v, %v", c.fen, c.iccs, str, mvParsed, err)
+		}
+	}
+}
+
+//TestWXFRoundTrip 每个局面的每个合法走法都能转换成WXF记谱再解析回来
+func TestWXFRoundTrip(t *testing.T) {
+	for _, fen := range []string{"", fenTwoRooks, fenThreePawns, fenFourPawns, fenTandemPawns, fenBlackPieces} {
+		p := notationPosition(t, fen, nil)
+		for _, mv := range p.LegalMoves() {
+			str, err := p.WXF(mv)
+			if err != nil {
+				t.Errorf("%q %v: %v", fen, mv, err)
+				continue
+			}
+			if mvParsed, err := p.ParseWXF(str); err != nil || mvParsed != mv {
+				t.Errorf("%q %v: ParseWXF(%q) = %v, %v", fen, mv, str, mvParsed, err)
+			}
+		}
+	}
+}
+
+//TestParseWXFVariants 解析小写、B(相)、N(马)和用"="表示平的写法
+func TestParseWXFVariants(t *testing.T) {
+	p := NewPosition()
+	cases := []struct {
+		str  string
+		iccs string
+	}{
+		{"c2.5", "h2e2"},
+		{"C2=5", "h2e2"},
+		{" C2.5 ", "h2e2"},
+		{"N8+7", "b0c2"},
+		{"n2+3", "h0g2"},
+		{"B7+5", "c0e2"},
+	}
+	for _, c := range cases {
+		if mv, err := p.ParseWXF(c.str); err != nil || mv.String() != c.iccs {
+			t.Errorf("%q: got %v, %v, want %s", c.str, mv, err, c.iccs)
+		}
+	}
+	for _, str := range []string{"", "C2.", "C2*5", "X2.5", "C0.5", "C2.0", "R2+1", "+C.5"} {
+		if mv, err := p.ParseWXF(str); err == nil {
+			t.Errorf("%q: got %v, want error", str, mv)
+		}
+	}
+}
+
+//TestWXFIllegal 不是走子方合法走法的走法不能记谱
+func TestWXFIllegal(t *testing.T) {
+	cases := []struct {
+		fen  string
+		iccs string
+	}{
+		{"", "h7e7"}, //轮到红方走，不能给黑方的走法记谱
+		{"", "a0a5"}, //车不能越子
+		{"", "e1e2"}, //没有棋子
+		{"4k4/4r4/9/9/9/9/9/9/4R4/4K4 w - - 0 1", "e1a1"}, //车被牵制，走开就送将
+	}
+	for _, c := range cases {
+		p := notationPosition(t, c.fen, nil)
+		mv, _ := ParseMove(c.iccs)
+		if str, err := p.WXF(mv); err == nil {
+			t.Errorf("%q %s: got %q, want error", c.fen, c.iccs, str)
+		}
+	}
+	if str, err := NewPosition().WXF(0); err == nil {
+		t.Errorf("no move: got %q, want error", str)
+	}
+}
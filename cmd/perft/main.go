@@ -0,0 +1,53 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * Perft命令行工具
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"ChineseChess/chess"
+)
+
+func main() {
+	fen := flag.String("fen", chess.StartupFEN, "局面的FEN串")
+	depth := flag.Int("depth", 3, "搜索深度")
+	divide := flag.Bool("divide", false, "分别统计每个根节点走法")
+	flag.Parse()
+
+	pos, err := chess.NewPositionFromFEN(*fen)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	start := time.Now()
+	nNodes := 0
+	if *divide {
+		result := pos.Divide(*depth)
+		mvs := make([]string, 0, len(result))
+		counts := map[string]int{}
+		for mv, n := range result {
+			mvs = append(mvs, mv.String())
+			counts[mv.String()] = n
+			nNodes += n
+		}
+		sort.Strings(mvs)
+		for _, mv := range mvs {
+			fmt.Printf("%s: %d\n", mv, counts[mv])
+		}
+		fmt.Printf("moves: %d\n", len(mvs))
+	} else {
+		nNodes = pos.Perft(*depth)
+	}
+	elapsed := time.Since(start)
+	fmt.Printf("nodes: %d, time: %v\n", nNodes, elapsed)
+}
@@ -0,0 +1,142 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 暗棋界面
+ */
+
+package gui
+
+import (
+	"context"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/ebitenutil"
+
+	"ChineseChess/chess"
+)
+
+//暗棋棋盘在窗口中居中
+const (
+	BanqiLeft = (BoardWidth - chess.BanqiWidth*SquareSize) / 2
+	BanqiTop  = (BoardHeight - chess.BanqiHeight*SquareSize) / 2
+)
+
+var (
+	//colorBanqiBoard 暗棋棋盘底色
+	colorBanqiBoard = color.RGBA{0xe8, 0xc8, 0x88, 0xff}
+	//colorBanqiLine 暗棋棋盘格线
+	colorBanqiLine = color.RGBA{0x40, 0x20, 0x00, 0xff}
+	//colorBanqiHidden 暗子
+	colorBanqiHidden = color.RGBA{0x80, 0x50, 0x20, 0xff}
+)
+
+//drawBanqi 绘制暗棋棋盘
+func (g *Game) drawBanqi(screen *ebiten.Image) {
+	//棋盘
+	ebitenutil.DrawRect(screen, 0, 0, BoardWidth, BoardHeight, colorBanqiBoard)
+	for x := 0; x <= chess.BanqiWidth; x++ {
+		xPos := float64(BanqiLeft + x*SquareSize)
+		ebitenutil.DrawLine(screen, xPos, BanqiTop, xPos, BanqiTop+chess.BanqiHeight*SquareSize, colorBanqiLine)
+	}
+	for y := 0; y <= chess.BanqiHeight; y++ {
+		yPos := float64(BanqiTop + y*SquareSize)
+		ebitenutil.DrawLine(screen, BanqiLeft, yPos, BanqiLeft+chess.BanqiWidth*SquareSize, yPos, colorBanqiLine)
+	}
+
+	//棋子，暗子画成实心方块
+	for sq := 0; sq < chess.BanqiSquares; sq++ {
+		xPos := BanqiLeft + (sq%chess.BanqiWidth)*SquareSize
+		yPos := BanqiTop + (sq/chess.BanqiWidth)*SquareSize
+		if g.banqi.Hidden(sq) {
+			ebitenutil.DrawRect(screen, float64(xPos+4), float64(yPos+4), SquareSize-8, SquareSize-8, colorBanqiHidden)
+		} else if pc := g.banqi.Piece(sq); pc != 0 {
+			g.drawChess(xPos, yPos, screen, g.images[pc])
+		}
+		if sq == g.sqBanqiSel || sq == g.mvBanqiLast.Src || sq == g.mvBanqiLast.Dst {
+			g.drawChess(xPos, yPos, screen, g.images[ImgSelect])
+		}
+	}
+}
+
+//clickBanqi 点击暗棋棋盘处理
+func (g *Game) clickBanqi(x, y int) {
+	if x < BanqiLeft || y < BanqiTop {
+		return
+	}
+	x = (x - BanqiLeft) / SquareSize
+	y = (y - BanqiTop) / SquareSize
+	if x >= chess.BanqiWidth || y >= chess.BanqiHeight {
+		return
+	}
+	sq := x + y*chess.BanqiWidth
+
+	if g.banqi.Hidden(sq) {
+		//点击暗子就翻开
+		g.playBanqi(chess.BanqiMove{Src: sq, Dst: sq})
+	} else if pc := g.banqi.Piece(sq); pc != 0 && chess.PieceSide(pc) == g.banqi.Side() {
+		//如果点击自己的棋子，那么直接选中
+		g.sqBanqiSel = sq
+		g.playAudio(MusicSelect)
+	} else if g.sqBanqiSel >= 0 {
+		//如果点击的不是自己的棋子，但有棋子选中了，那么走这个棋子
+		g.playBanqi(chess.BanqiMove{Src: g.sqBanqiSel, Dst: sq})
+	}
+}
+
+//playBanqi 玩家走一步暗棋，然后轮到电脑走
+func (g *Game) playBanqi(mv chess.BanqiMove) {
+	bFirst := g.banqi.Side() < 0
+	if g.banqi.Play(mv) != nil {
+		return
+	}
+	//第一步翻开的棋子决定玩家执哪一方
+	if bFirst {
+		g.sdBanqiHuman = 1 - g.banqi.Side()
+	}
+	g.mvBanqiLast = mv
+	g.sqBanqiSel = -1
+	if g.showResult(g.banqi.Result(), g.sdBanqiHuman) {
+		return
+	}
+	g.playBanqiAudio()
+
+	//电脑走一步棋
+	g.banqiAIMove()
+}
+
+//banqiAIMove 暗棋电脑在后台复制的对局上思考，界面不会卡住
+func (g *Game) banqiAIMove() {
+	ctx, cancel := context.WithCancel(context.Background())
+	b := g.banqi.Clone()
+	ch := make(chan chess.BanqiMove, 1)
+	g.wgAI.Add(1)
+	go func() {
+		defer g.wgAI.Done()
+		//没有走法时送的是不合法的NoBanqiMove，电脑就不走
+		mv, _ := b.SearchContext(ctx, chess.SearchLimits{})
+		ch <- mv
+	}()
+	g.chBanqiMove, g.cancelAI = ch, cancel
+}
+
+//playBanqiAIMove 走暗棋电脑想好的棋
+func (g *Game) playBanqiAIMove(mv chess.BanqiMove) {
+	if g.banqi.Play(mv) != nil {
+		return
+	}
+	g.mvBanqiLast = mv
+	if !g.showResult(g.banqi.Result(), g.sdBanqiHuman) {
+		g.playBanqiAudio()
+	}
+}
+
+//playBanqiAudio 播放吃子或一般走子的声音
+func (g *Game) playBanqiAudio() {
+	if g.banqi.Captured() {
+		g.playAudio(MusicEat)
+	} else {
+		g.playAudio(MusicPut)
+	}
+}
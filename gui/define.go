@@ -0,0 +1,77 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * 常用定义
+ */
+
+package gui
+
+const (
+	//ImgChessBoard 棋盘
+	ImgChessBoard = 1
+	//ImgSelect 选中
+	ImgSelect = 2
+	//ImgRedShuai 红帅
+	ImgRedShuai = 8
+	//ImgRedShi 红士
+	ImgRedShi = 9
+	//ImgRedXiang 红相
+	ImgRedXiang = 10
+	//ImgRedMa 红马
+	ImgRedMa = 11
+	//ImgRedJu 红车
+	ImgRedJu = 12
+	//ImgRedPao 红炮
+	ImgRedPao = 13
+	//ImgRedBing 红兵
+	ImgRedBing = 14
+	//ImgBlackJiang 黑将
+	ImgBlackJiang = 16
+	//ImgBlackShi 黑士
+	ImgBlackShi = 17
+	//ImgBlackXiang 黑相
+	ImgBlackXiang = 18
+	//ImgBlackMa 黑马
+	ImgBlackMa = 19
+	//ImgBlackJu 黑车
+	ImgBlackJu = 20
+	//ImgBlackPao 黑炮
+	ImgBlackPao = 21
+	//ImgBlackBing 黑兵
+	ImgBlackBing = 22
+)
+
+const (
+	//MusicSelect 选子
+	MusicSelect = 100
+	//MusicPut 落子
+	MusicPut = 101
+	//MusicEat 吃子
+	MusicEat = 102
+	//MusicJiang 将军
+	MusicJiang = 103
+	//MusicGameWin 胜利
+	MusicGameWin = 104
+	//MusicGameLose 失败
+	MusicGameLose = 105
+)
+
+//游戏模式
+const (
+	//ModeXiangqi 中国象棋
+	ModeXiangqi = 0
+	//ModeBanqi 暗棋
+	ModeBanqi = 1
+)
+
+//窗口
+const (
+	SquareSize  = 56
+	BoardEdge   = 8
+	BoardWidth  = BoardEdge + SquareSize*9 + BoardEdge
+	BoardHeight = BoardEdge + SquareSize*10 + BoardEdge
+)
+
+//RecordFile 保存棋谱的文件
+const RecordFile = "record.txt"
@@ -0,0 +1,405 @@
+/**
+ * 中国象棋
+ * Designed by wqh, Version: 1.0
+ * Copyright (C) 2020 www.wangqianhong.com
+ * GUI图形界面
+ */
+
+package gui
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/png"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang/freetype/truetype"
+	"github.com/hajimehoshi/ebiten/examples/resources/fonts"
+	"github.com/hajimehoshi/ebiten/inpututil"
+	"github.com/hajimehoshi/ebiten/text"
+	"golang.org/x/image/font"
+
+	"ChineseChess/chess"
+
+	"github.com/hajimehoshi/ebiten"
+	"github.com/hajimehoshi/ebiten/audio"
+	"github.com/hajimehoshi/ebiten/audio/wav"
+)
+
+//Game 象棋窗口
+type Game struct {
+	sqSelected     int                   //选中的格子
+	mvLast         chess.Move            //上一步棋
+	bFlipped       bool                  //是否翻转棋盘
+	bGameOver      bool                  //是否游戏结束
+	showValue      string                //显示内容
+	showReason     string                //对局结束的原因
+	images         map[int]*ebiten.Image //图片资源
+	audios         map[int]*audio.Player //音效
+	audioContext   *audio.Context        //音效器
+	singlePosition *chess.Position       //棋局单例
+	enginePosition *chess.Position       //电脑思考用的对局，置换表和历史表在各步之间复用
+	mode           int                   //游戏模式
+	banqi          *chess.Banqi          //暗棋对局
+	sqBanqiSel     int                   //暗棋选中的格子，没有选中为-1
+	mvBanqiLast    chess.BanqiMove       //暗棋上一步棋
+	sdBanqiHuman   int                   //暗棋中玩家执哪一方
+	chAIMove       chan chess.Move       //电脑思考的结果，没有在思考时为nil
+	chBanqiMove    chan chess.BanqiMove  //暗棋电脑思考的结果，没有在思考时为nil
+	cancelAI       context.CancelFunc    //取消电脑思考
+	wgAI           sync.WaitGroup        //等待电脑思考的goroutine退出
+}
+
+//NewGame 创建象棋程序
+func NewGame() bool {
+	game := &Game{
+		images:         make(map[int]*ebiten.Image),
+		audios:         make(map[int]*audio.Player),
+		singlePosition: chess.NewPosition(),
+		banqi:          chess.NewBanqi(time.Now().UnixNano()),
+		sqBanqiSel:     -1,
+		mvBanqiLast:    chess.NoBanqiMove,
+	}
+	if game == nil || game.singlePosition == nil {
+		return false
+	}
+
+	var err error
+	//音效器
+	game.audioContext, err = audio.NewContext(48000)
+	if err != nil {
+		fmt.Print(err)
+		return false
+	}
+
+	//加载资源
+	if ok := game.loadResource(); !ok {
+		return false
+	}
+
+	//加载开局库
+	game.singlePosition.LoadBook()
+
+	//设置窗口，接收信息
+	ebiten.SetWindowSize(BoardWidth, BoardHeight)
+	ebiten.SetWindowTitle("中国象棋")
+	if err := ebiten.RunGame(game); err != nil {
+		fmt.Print(err)
+		return false
+	}
+
+	return true
+}
+
+//Update 更新状态，1秒60帧
+func (g *Game) Update(screen *ebiten.Image) error {
+	//按M键切换中国象棋和暗棋
+	if inpututil.IsKeyJustPressed(ebiten.KeyM) {
+		g.mode = 1 - g.mode
+		g.restart()
+	}
+	if g.mode == ModeXiangqi {
+		//按H键轮换让子
+		if inpututil.IsKeyJustPressed(ebiten.KeyH) {
+			g.nextHandicap()
+		}
+		//按S键保存棋谱
+		if inpututil.IsKeyJustPressed(ebiten.KeyS) {
+			g.saveRecord()
+		}
+	}
+
+	//电脑在后台思考，想好了就走棋
+	if g.aiThinking() {
+		select {
+		case mv := <-g.chAIMove:
+			g.stopAI()
+			g.playAIMove(mv)
+		case mv := <-g.chBanqiMove:
+			g.stopAI()
+			g.playBanqiAIMove(mv)
+		default:
+		}
+	}
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		if g.bGameOver {
+			g.restart()
+		} else if g.mode == ModeBanqi && !g.aiThinking() {
+			g.clickBanqi(ebiten.CursorPosition())
+		} else if g.mode == ModeXiangqi && !g.aiThinking() {
+			x, y := ebiten.CursorPosition()
+			x = chess.Left + (x-BoardEdge)/SquareSize
+			y = chess.Top + (y-BoardEdge)/SquareSize
+			g.clickSquare(screen, chess.SquareXY(x, y))
+		}
+	}
+
+	if g.mode == ModeBanqi {
+		g.drawBanqi(screen)
+	} else {
+		g.drawBoard(screen)
+	}
+	if g.bGameOver {
+		g.messageBox(screen)
+	}
+	return nil
+}
+
+//restart 重新开始当前模式的对局
+func (g *Game) restart() {
+	g.stopAI()
+	g.bGameOver = false
+	g.showValue = ""
+	g.showReason = ""
+	g.sqSelected = 0
+	g.mvLast = 0
+	g.singlePosition.Reset()
+	g.sqBanqiSel = -1
+	g.mvBanqiLast = chess.NoBanqiMove
+	g.banqi.Reset(time.Now().UnixNano())
+}
+
+//nextHandicap 换成下一种让子并重新开始
+func (g *Game) nextHandicap() {
+	h := (g.singlePosition.Handicap() + 1) % chess.Handicap(len(chess.Handicaps()))
+	g.singlePosition.SetHandicap(h)
+	g.restart()
+	if h == chess.HandicapNone {
+		ebiten.SetWindowTitle("中国象棋")
+	} else {
+		ebiten.SetWindowTitle("中国象棋 - " + h.String())
+	}
+}
+
+//saveRecord 把当前对局的棋谱保存到RecordFile
+func (g *Game) saveRecord() {
+	if err := os.WriteFile(RecordFile, []byte(g.singlePosition.Record()), 0644); err != nil {
+		fmt.Print(err)
+	}
+}
+
+//Layout 布局采用外部尺寸（例如，窗口尺寸）并返回（逻辑）屏幕尺寸，如果不使用外部尺寸，只需返回固定尺寸即可。
+func (g *Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
+	return BoardWidth, BoardHeight
+}
+
+//loadResource 加载资源
+func (g *Game) loadResource() bool {
+	for k, v := range resMap {
+		if k >= MusicSelect {
+			//加载音效
+			d, err := wav.Decode(g.audioContext, audio.BytesReadSeekCloser(v))
+			if err != nil {
+				fmt.Print(err)
+				return false
+			}
+			player, err := audio.NewPlayer(g.audioContext, d)
+			if err != nil {
+				fmt.Print(err)
+				return false
+			}
+			g.audios[k] = player
+		} else {
+			//加载图片
+			img, _, err := image.Decode(bytes.NewReader(v))
+			if err != nil {
+				fmt.Print(err)
+				return false
+			}
+			ebitenImage, _ := ebiten.NewImageFromImage(img, ebiten.FilterDefault)
+			g.images[k] = ebitenImage
+		}
+	}
+
+	return true
+}
+
+//drawBoard 绘制棋盘
+func (g *Game) drawBoard(screen *ebiten.Image) {
+	//棋盘
+	if v, ok := g.images[ImgChessBoard]; ok {
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(0, 0)
+		screen.DrawImage(v, op)
+	}
+
+	//棋子
+	for x := chess.Left; x <= chess.Right; x++ {
+		for y := chess.Top; y <= chess.Bottom; y++ {
+			xPos, yPos := 0, 0
+			if g.bFlipped {
+				xPos = BoardEdge + (chess.Right-x)*SquareSize
+				yPos = BoardEdge + (chess.Bottom-y)*SquareSize
+			} else {
+				xPos = BoardEdge + (x-chess.Left)*SquareSize
+				yPos = BoardEdge + (y-chess.Top)*SquareSize
+			}
+			sq := chess.SquareXY(x, y)
+			pc := g.singlePosition.Piece(sq)
+			if pc != 0 {
+				g.drawChess(xPos, yPos+5, screen, g.images[pc])
+			}
+			if sq == g.sqSelected || sq == g.mvLast.Src() || sq == g.mvLast.Dst() {
+				g.drawChess(xPos, yPos, screen, g.images[ImgSelect])
+			}
+		}
+	}
+}
+
+//drawChess 绘制棋子
+func (g *Game) drawChess(x, y int, screen, img *ebiten.Image) {
+	if img == nil {
+		return
+	}
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(float64(x), float64(y))
+	screen.DrawImage(img, op)
+}
+
+//clickSquare 点击格子处理
+func (g *Game) clickSquare(screen *ebiten.Image, sq int) {
+	pc := 0
+	if g.bFlipped {
+		pc = g.singlePosition.Piece(chess.SquareFlip(sq))
+	} else {
+		pc = g.singlePosition.Piece(sq)
+	}
+
+	if chess.PieceSide(pc) == g.singlePosition.Side() {
+		//如果点击自己的棋子，那么直接选中
+		g.sqSelected = sq
+		g.playAudio(MusicSelect)
+	} else if g.sqSelected != 0 && !g.bGameOver {
+		//如果点击的不是自己的棋子，但有棋子选中了(一定是自己的棋子)，那么走这个棋子
+		mv := chess.NewMove(g.sqSelected, sq)
+		switch g.singlePosition.Play(mv) {
+		case nil:
+			g.mvLast = mv
+			g.sqSelected = 0
+			//如果分出胜负，那么弹出提示框，否则轮到电脑走
+			if !g.checkResult() {
+				g.playMoveAudio()
+				g.aiMove(screen)
+			}
+		case chess.ErrSelfCheck:
+			g.playAudio(MusicJiang) //播放被将军的声音
+		}
+		//如果根本就不符合走法(例如马不走日字)，那么不做任何处理
+	}
+}
+
+//playAudio 播放音效
+func (g *Game) playAudio(value int) {
+	if player, ok := g.audios[value]; ok {
+		player.Rewind()
+		player.Play()
+	}
+}
+
+//playMoveAudio 播放将军、吃子或一般走子的声音
+func (g *Game) playMoveAudio() {
+	if g.singlePosition.IsCheck() {
+		g.playAudio(MusicJiang)
+	} else if g.singlePosition.Captured() {
+		g.playAudio(MusicEat)
+	} else {
+		g.playAudio(MusicPut)
+	}
+}
+
+//checkResult 检查对局结果，分出胜负或和棋时播放声音并弹出提示框
+func (g *Game) checkResult() bool {
+	//玩家执红，翻转棋盘时执黑
+	sdHuman := chess.Red
+	if g.bFlipped {
+		sdHuman = chess.Black
+	}
+	return g.showResult(g.singlePosition.Result(), sdHuman)
+}
+
+//showResult 分出胜负或和棋时播放声音并弹出提示框，sdHuman是玩家执哪一方
+func (g *Game) showResult(result chess.Result, sdHuman int) bool {
+	switch result.Outcome {
+	case chess.OutcomeNone:
+		return false
+	case chess.OutcomeDraw:
+		g.playAudio(MusicGameWin)
+		g.showValue = "Your Draw!"
+	case chess.OutcomeRedWin, chess.OutcomeBlackWin:
+		if result.Winner() == sdHuman {
+			g.playAudio(MusicGameWin)
+			g.showValue = "Your Win!"
+		} else {
+			g.playAudio(MusicGameLose)
+			g.showValue = "Your Lose!"
+		}
+	}
+	g.showReason = result.String()
+	g.bGameOver = true
+	return true
+}
+
+//aiMove AI在后台的对局上思考，界面不会卡住，后台对局一直留着，不用每步都重新分配置换表
+func (g *Game) aiMove(screen *ebiten.Image) {
+	ctx, cancel := context.WithCancel(context.Background())
+	g.enginePosition = g.singlePosition.CloneInto(g.enginePosition)
+	pos := g.enginePosition
+	ch := make(chan chess.Move, 1)
+	g.wgAI.Add(1)
+	go func() {
+		defer g.wgAI.Done()
+		ch <- pos.SearchContext(ctx, chess.SearchLimits{})
+	}()
+	g.chAIMove, g.cancelAI = ch, cancel
+}
+
+//aiThinking 电脑是否正在思考
+func (g *Game) aiThinking() bool {
+	return g.chAIMove != nil || g.chBanqiMove != nil
+}
+
+//stopAI 取消电脑思考，等后台的搜索退出之后才返回，后台对局才能给下一次思考用
+func (g *Game) stopAI() {
+	if g.cancelAI != nil {
+		g.cancelAI()
+	}
+	g.wgAI.Wait()
+	g.chAIMove, g.chBanqiMove, g.cancelAI = nil, nil, nil
+}
+
+//playAIMove 走电脑想好的棋
+func (g *Game) playAIMove(mv chess.Move) {
+	if g.singlePosition.Play(mv) != nil {
+		return
+	}
+	//把AI走的棋标记出来
+	g.mvLast = mv
+	if !g.checkResult() {
+		g.playMoveAudio()
+	}
+}
+
+//messageBox 提示
+func (g *Game) messageBox(screen *ebiten.Image) {
+	fmt.Println(g.showValue, g.showReason)
+	tt, err := truetype.Parse(fonts.ArcadeN_ttf)
+	if err != nil {
+		fmt.Print(err)
+		return
+	}
+	arcadeFont := truetype.NewFace(tt, &truetype.Options{
+		Size:    16,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+
+	text.Draw(screen, g.showValue, arcadeFont, 180, 288, color.White)
+	text.Draw(screen, g.showReason, arcadeFont, 100, 304, color.White)
+	text.Draw(screen, "Click mouse to restart", arcadeFont, 100, 320, color.White)
+}
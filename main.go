@@ -8,9 +8,9 @@
 package main
 
 import (
-	"ChineseChess/chess"
+	"ChineseChess/gui"
 )
 
 func main() {
-	chess.NewGame()
+	gui.NewGame()
 }
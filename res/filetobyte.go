@@ -21,7 +21,7 @@ func FileToByte(inPath, outPath string) error {
 	defer fOut.Close()
 
 	//写入包名
-	if _, err := fmt.Fprintf(fOut, "package chess\n\n"); err != nil {
+	if _, err := fmt.Fprintf(fOut, "package gui\n\n"); err != nil {
 		return err
 	}
 